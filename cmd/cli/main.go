@@ -1,12 +1,15 @@
 // Package main реализует точку запуска приложения.
-// В этом пакете создается .json файл (если его нет), в котором будут храниться созданные задачи пользователя.
+// В этом пакете определяется расположение .json файла с задачами пользователя и файл создается, если его нет.
 // После чего вызывается обработчик пользовательских команд.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"strings"
 
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
 	cyclehandler "github.com/NikitaTumanov/terminalTaskTracker/internal/cycle_handler"
 	filemanager "github.com/NikitaTumanov/terminalTaskTracker/internal/file_manager"
 	flaghandler "github.com/NikitaTumanov/terminalTaskTracker/internal/flag_handler"
@@ -14,45 +17,123 @@ import (
 
 type handler interface {
 	Handle() error
-	Update()
+	Update(ctx context.Context) error
 }
 
 // main запускает работу приложения.
-// Функция создает в той же директории JSON файл для записи задач и вызывает метод обработки команд.
+// Функция загружает настройки (см. config.ResolvePath), выбирает JSON файл для записи задач
+// (см. filemanager.ResolveLocation), создает его при необходимости и вызывает метод обработки команд.
 // При возникновении ошибки при работе с файлом приложение прекращает работу.
 func main() {
 	var (
-		handler    handler
-		command    string
-		taskIndex  = flag.Int("index", -1, "index")
-		taskName   = flag.String("name", "", "name")
-		taskStatus = flag.String("status", "", "status")
-		helpFlag   = flag.Bool("help", false, "help")
+		handler     handler
+		command     string
+		taskIndex   = flag.String("index", "", "index")
+		taskName    = flag.String("name", "", "name")
+		taskStatus  = flag.String("status", "", "status")
+		helpFlag    = flag.Bool("help", false, "help")
+		filePath    = flag.String("file", "", "file")
+		configPath  = flag.String("config", "", "config")
+		sortKey     = flag.String("sort", "", "sort")
+		due         = flag.String("due", "", "due")
+		priority    = flag.String("priority", "", "priority")
+		tags        = flag.String("tags", "", "tags")
+		project     = flag.String("project", "", "project")
+		parent      = flag.String("parent", "", "parent")
+		subtasks    = flag.String("subtasks", "", "subtasks")
+		depends     = flag.String("depends", "", "depends")
+		recur       = flag.String("recur", "", "recur")
+		description = flag.String("description", "", "description")
+		note        = flag.String("note", "", "note")
+		filter      = flag.String("filter", "", "filter")
+		groupBy     = flag.String("group-by", "", "group-by")
+		columns     = flag.String("columns", "", "columns")
+		since       = flag.String("since", "", "since")
+		all         = flag.Bool("all", false, "all")
+		yes         = flag.Bool("yes", false, "yes")
+		format      = flag.String("format", "", "format")
 	)
 	flag.StringVar(&command, "c", "", "command")
 	flag.Parse()
 
-	err := filemanager.CreateFile()
+	cfgPath, err := config.ResolvePath(*configPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	filemanager.SetWorkflow(cfg.Statuses)
+	filemanager.SetReports(cfg.Reports)
+	filemanager.SetConfirmThreshold(cfg.ConfirmThreshold)
+
+	location, err := filemanager.ResolveLocation(*filePath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	store, err := filemanager.NewJSONStore(location)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
 	if command == "" && !(*helpFlag) {
-		handler, err = cyclehandler.New()
+		filemanager.SetMode(filemanager.ModeREPL)
+		handler, err = cyclehandler.New(store, cfgPath)
 		if err != nil {
 			fmt.Println(err)
+			return
 		}
 
 	} else {
-		handler, err = flaghandler.New(*taskIndex, command, *taskName, *taskStatus, *helpFlag)
+		filemanager.SetMode(filemanager.ModeFlag)
+		handler, err = flaghandler.New(store, flaghandler.Options{
+			TaskIndex:   *taskIndex,
+			Command:     command,
+			TaskName:    *taskName,
+			TaskStatus:  *taskStatus,
+			Help:        *helpFlag,
+			Sort:        *sortKey,
+			Due:         *due,
+			Priority:    *priority,
+			Tags:        *tags,
+			Project:     *project,
+			Parent:      *parent,
+			Subtasks:    *subtasks,
+			Depends:     *depends,
+			Recur:       *recur,
+			Description: *description,
+			Note:        *note,
+			Filter:      *filter,
+			Args:        strings.Join(flag.Args(), " "),
+			GroupBy:     *groupBy,
+			Columns:     *columns,
+			Since:       *since,
+			All:         *all,
+			Yes:         *yes,
+			Format:      *format,
+		})
 		if err != nil {
 			fmt.Println(err)
+			return
 		}
 
 	}
 
-	handler.Update()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = handler.Update(ctx)
+	if err != nil {
+		fmt.Println(err)
+	}
+
 	err = handler.Handle()
 	if err != nil {
 		fmt.Println(err)
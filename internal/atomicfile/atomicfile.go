@@ -0,0 +1,51 @@
+// Package atomicfile атомарно записывает файлы: при сбое во время записи файл не остается обрезанным.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile записывает данные во временный файл в той же директории, сбрасывает их на диск
+// и переименовывает временный файл в целевой. Таким образом, при сбое во время записи
+// целевой файл остается либо в старом, либо в новом состоянии, но никогда не обрезанным.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	err = os.Chmod(tmpPath, perm)
+	if err != nil {
+		return fmt.Errorf("os.Chmod: %w", err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return syncDir(dir)
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+)
+
+// syncDir сбрасывает на диск содержимое директории, чтобы переименование файла пережило сбой питания.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir: %w", err)
+	}
+	defer d.Close()
+
+	err = d.Sync()
+	if err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+
+	return nil
+}
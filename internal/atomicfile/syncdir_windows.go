@@ -0,0 +1,9 @@
+//go:build windows
+
+package atomicfile
+
+// syncDir ничего не делает на Windows: директорию нельзя открыть для Sync,
+// а переименование в NTFS журналируется самой файловой системой.
+func syncDir(dir string) error {
+	return nil
+}
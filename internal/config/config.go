@@ -0,0 +1,334 @@
+// Package config загружает настройки трекера задач из JSON-файла: набор статусов задач,
+// допустимые переходы между ними, сохраненные отчеты и порог подтверждения массовых изменений.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/atomicfile"
+)
+
+const (
+	EnvConfigFile   = "TASKTRACKER_CONFIG"
+	defaultFileName = "config.json"
+	appDirName      = "tasktracker"
+)
+
+var (
+	ErrInvalidConfig  error = errors.New("invalid config")
+	ErrReportNotFound error = errors.New("report not found")
+)
+
+// Status описывает статус задачи. Name — название, по которому статус указывается в командах и хранится в файле задач,
+// Key — короткое название для ввода. Done означает, что задача в этом статусе считается завершенной.
+// Transitions — названия статусов, в которые можно перевести задачу; пустой список разрешает любой переход.
+type Status struct {
+	Name        string   `json:"name"`
+	Key         string   `json:"key,omitempty"`
+	Done        bool     `json:"done,omitempty"`
+	Transitions []string `json:"transitions,omitempty"`
+}
+
+// Workflow — упорядоченный набор статусов. Первый статус присваивается новым задачам.
+type Workflow []Status
+
+// Report — сохраненный отчет: список задач с фильтром (см. пакет query), ключами сортировки, группировкой
+// и набором колонок. Пустые поля означают значения по умолчанию.
+type Report struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Filter      string   `json:"filter,omitempty"`
+	Sort        string   `json:"sort,omitempty"`
+	GroupBy     string   `json:"group_by,omitempty"`
+	Columns     []string `json:"columns,omitempty"`
+}
+
+// reservedReportNames — подкоманды команды report, которые нельзя использовать как названия отчетов.
+var reservedReportNames = []string{"add", "delete", "list"}
+
+// Config — настройки приложения. ConfirmThreshold — количество задач, больше которого массовое изменение
+// выполняется только после подтверждения пользователя; при 0 подтверждение запрашивается для любого изменения.
+type Config struct {
+	Statuses         Workflow `json:"statuses"`
+	Reports          []Report `json:"reports,omitempty"`
+	ConfirmThreshold int      `json:"confirm_threshold"`
+}
+
+// Default возвращает настройки по умолчанию, которые используются, если файла настроек нет.
+func Default() Config {
+	return Config{
+		Statuses: Workflow{
+			{Name: "todo", Key: "t"},
+			{Name: "in-progress", Key: "i"},
+			{Name: "done", Key: "d", Done: true},
+		},
+		ConfirmThreshold: 5,
+	}
+}
+
+// ResolvePath определяет путь к файлу настроек. Порядок выбора: флаг --config,
+// переменная окружения TASKTRACKER_CONFIG и $XDG_CONFIG_HOME/tasktracker/config.json.
+func ResolvePath(flagPath string) (string, error) {
+	if flagPath != "" {
+		return filepath.Abs(flagPath)
+	}
+
+	if envPath := os.Getenv(EnvConfigFile); envPath != "" {
+		return filepath.Abs(envPath)
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("os.UserConfigDir: %w", err)
+	}
+
+	return filepath.Join(dir, appDirName, defaultFileName), nil
+}
+
+// Load считывает настройки из файла. Если файла нет, возвращаются настройки по умолчанию.
+// Разделы, не указанные в файле, также берутся из настроек по умолчанию.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	file := Config{ConfirmThreshold: cfg.ConfirmThreshold}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	if len(file.Statuses) > 0 {
+		cfg.Statuses = file.Statuses
+	}
+	cfg.Reports = file.Reports
+	cfg.ConfirmThreshold = file.ConfirmThreshold
+
+	err = cfg.Statuses.validate()
+	if err == nil {
+		err = validateReports(cfg.Reports)
+	}
+	if err == nil && cfg.ConfirmThreshold < 0 {
+		err = fmt.Errorf("confirm_threshold %d must not be negative", cfg.ConfirmThreshold)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	return cfg, nil
+}
+
+// validate проверяет, что названия и короткие названия статусов уникальны, переходы ссылаются
+// на существующие статусы, первый статус не завершает задачу, а хотя бы один статус завершает.
+func (w Workflow) validate() error {
+	names := make(map[string]bool)
+	for _, status := range w {
+		if status.Name == "" || strings.ContainsAny(status.Name, " \t:,") {
+			return fmt.Errorf("status name %q must be non-empty and must not contain spaces, ':' or ','", status.Name)
+		}
+
+		for _, name := range []string{status.Name, status.Key} {
+			if name == "" {
+				continue
+			}
+			if names[strings.ToLower(name)] {
+				return fmt.Errorf("status name or key %q is used twice", name)
+			}
+			names[strings.ToLower(name)] = true
+		}
+	}
+
+	for _, status := range w {
+		for _, to := range status.Transitions {
+			if _, ok := w.Find(to); !ok {
+				return fmt.Errorf("status %q: unknown transition target %q", status.Name, to)
+			}
+		}
+	}
+
+	if w[0].Done {
+		return fmt.Errorf("the first status %q is assigned to new tasks and cannot be done", w[0].Name)
+	}
+	if !slices.ContainsFunc(w, func(s Status) bool { return s.Done }) {
+		return errors.New("at least one status must be done")
+	}
+
+	return nil
+}
+
+// Find возвращает статус по названию или короткому названию без учета регистра.
+func (w Workflow) Find(ref string) (Status, bool) {
+	ref = strings.TrimSpace(ref)
+	for _, status := range w {
+		if strings.EqualFold(status.Name, ref) || (status.Key != "" && strings.EqualFold(status.Key, ref)) {
+			return status, true
+		}
+	}
+
+	return Status{}, false
+}
+
+// Initial возвращает статус, который присваивается новым задачам.
+func (w Workflow) Initial() Status {
+	return w[0]
+}
+
+// IsDone сообщает, считается ли задача в статусе name завершенной.
+func (w Workflow) IsDone(name string) bool {
+	status, ok := w.Find(name)
+	return ok && status.Done
+}
+
+// Allowed сообщает, можно ли перевести задачу из статуса from в статус to.
+// Переходы из статуса, которого нет в настройках, не ограничиваются.
+func (w Workflow) Allowed(from, to string) bool {
+	if strings.EqualFold(from, to) {
+		return true
+	}
+
+	status, ok := w.Find(from)
+	if !ok || len(status.Transitions) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(status.Transitions, func(name string) bool { return strings.EqualFold(name, to) })
+}
+
+// Names возвращает названия всех статусов по порядку.
+func (w Workflow) Names() []string {
+	names := make([]string, 0, len(w))
+	for _, status := range w {
+		names = append(names, status.Name)
+	}
+
+	return names
+}
+
+// BuiltinReports возвращает встроенные отчеты. Отчет из файла настроек с тем же названием заменяет встроенный.
+func BuiltinReports() []Report {
+	return []Report{
+		{Name: "all", Description: "All tasks"},
+		{Name: "next", Description: "Open tasks, most urgent first", Filter: "status:open", Sort: "urgency"},
+		{Name: "done", Description: "Done tasks, recently completed first", Filter: "status:closed", Sort: "completed:desc"},
+		{Name: "notdone", Description: "Tasks that are not started yet", Filter: "status:new"},
+		{Name: "inprogress", Description: "Started tasks", Filter: "status:started"},
+		{Name: "weekly", Description: "Open tasks due by the end of the week", Filter: "status:open due<=eow", Sort: "due,priority", GroupBy: "status"},
+	}
+}
+
+// validateReports проверяет, что названия отчетов заполнены, уникальны, не содержат пробелов
+// и не совпадают с подкомандами команды report.
+func validateReports(reports []Report) error {
+	names := make(map[string]bool)
+	for _, report := range reports {
+		name := strings.ToLower(report.Name)
+		if name == "" || strings.ContainsAny(name, " \t:") {
+			return fmt.Errorf("report name %q must be non-empty and must not contain spaces or ':'", report.Name)
+		}
+		if slices.Contains(reservedReportNames, name) {
+			return fmt.Errorf("report name %q is reserved", report.Name)
+		}
+		if names[name] {
+			return fmt.Errorf("report name %q is used twice", report.Name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// SaveReport добавляет отчет в файл настроек или заменяет отчет с тем же названием и возвращает
+// сохраненные в файле отчеты. Остальные разделы файла не изменяются. Если файла нет, он создается.
+func SaveReport(path string, report Report) ([]Report, error) {
+	return updateReports(path, func(reports []Report) ([]Report, error) {
+		i := slices.IndexFunc(reports, func(r Report) bool { return strings.EqualFold(r.Name, report.Name) })
+		if i >= 0 {
+			reports[i] = report
+			return reports, nil
+		}
+		return append(reports, report), nil
+	})
+}
+
+// DeleteReport удаляет отчет из файла настроек и возвращает оставшиеся в файле отчеты.
+func DeleteReport(path string, name string) ([]Report, error) {
+	return updateReports(path, func(reports []Report) ([]Report, error) {
+		i := slices.IndexFunc(reports, func(r Report) bool { return strings.EqualFold(r.Name, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrReportNotFound, name)
+		}
+		return slices.Delete(reports, i, i+1), nil
+	})
+}
+
+// updateReports изменяет раздел reports файла настроек функцией fn. Остальные разделы файла сохраняются как есть.
+// Файл записывается атомарно (см. atomicfile.WriteFile), чтобы не повредить настройки при сбое.
+func updateReports(path string, fn func(reports []Report) ([]Report, error)) ([]Report, error) {
+	sections := make(map[string]json.RawMessage)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &sections)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+		}
+	}
+
+	var reports []Report
+	if raw, ok := sections["reports"]; ok {
+		err = json.Unmarshal(raw, &reports)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+		}
+	}
+
+	reports, err = fn(reports)
+	if err != nil {
+		return nil, err
+	}
+	err = validateReports(reports)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	delete(sections, "reports")
+	if len(reports) > 0 {
+		raw, err := json.Marshal(reports)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal: %w", err)
+		}
+		sections["reports"] = raw
+	}
+
+	data, err = json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	err = atomicfile.WriteFile(path, append(data, '\n'), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	return reports, nil
+}
@@ -4,61 +4,205 @@ package cyclehandler
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
 	filemanager "github.com/NikitaTumanov/terminalTaskTracker/internal/file_manager"
 	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
 )
 
+// storage хранит задачи, с которыми работает интерактивный терминал.
+// Доступ к задачам защищен мьютексом, так как их обновляет горутина наблюдения за хранилищем.
 type storage struct {
+	store filemanager.Store
+	mu    sync.Mutex
 	tasks []models.Task
+	// configPath — путь к файлу настроек, в который сохраняются отчеты.
+	configPath string
+	// editing — открыт редактор задачи. Пока он открыт, сообщения об изменении задач не выводятся,
+	// чтобы не портить экран редактора.
+	editing atomic.Bool
 }
 
-func New() (*storage, error) {
-	tasks, err := filemanager.GetAllTasks()
+func New(store filemanager.Store, configPath string) (*storage, error) {
+	tasks, err := store.List()
 	if err != nil {
-		return &storage{}, fmt.Errorf("filemanager.GetAllTasks: %w", err)
+		return &storage{}, fmt.Errorf("store.List: %w", err)
 	}
 
 	return &storage{
-		tasks: tasks,
+		store:      store,
+		tasks:      tasks,
+		configPath: configPath,
 	}, nil
 }
 
-// Update запускает горутину для обновления слайса в структуре актуальной информаией из JSON.
-func (s *storage) Update() {
+// Update подписывается на изменения хранилища и при каждом изменении перечитывает задачи.
+// Если задачи изменил другой процесс, пользователь получает об этом сообщение. Наблюдение прекращается после отмены ctx.
+func (s *storage) Update(ctx context.Context) error {
+	changes, err := s.store.Watch(ctx)
+	if err != nil {
+		return fmt.Errorf("store.Watch: %w", err)
+	}
+
 	go func() {
-		var err error
-		for {
-			time.Sleep(models.TimeOut)
-			s.tasks, err = filemanager.GetAllTasks()
+		for range changes {
+			changed, err := s.reload()
 			if err != nil {
-				fmt.Println("filemanager.GetAllTasks: ", err)
+				fmt.Println("reload: ", err)
+				continue
+			}
+
+			if changed && !s.editing.Load() {
+				fmt.Print("\nTasks changed externally, list reloaded\nEnter the command: ")
 			}
 		}
 	}()
+
+	return nil
+}
+
+// reload перечитывает задачи из хранилища и сообщает, отличаются ли они от текущих.
+// Блокировка берется до чтения, чтобы собственные изменения, которые еще записываются
+// под этой же блокировкой, не считались внешними.
+func (s *storage) reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, err := s.store.List()
+	if err != nil {
+		return false, fmt.Errorf("store.List: %w", err)
+	}
+
+	if reflect.DeepEqual(tasks, s.tasks) {
+		return false, nil
+	}
+
+	s.tasks = tasks
+	return true, nil
+}
+
+// snapshot возвращает копию текущих задач, чтобы вывод списка не удерживал блокировку.
+func (s *storage) snapshot() []models.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.tasks)
+}
+
+// listed возвращает задачи для вывода списка: с параметром --all к ним добавляются задачи из архива.
+func (s *storage) listed(opts filemanager.ListOptions) ([]models.Task, error) {
+	tasks := s.snapshot()
+	if !opts.All {
+		return tasks, nil
+	}
+
+	return filemanager.WithArchived(s.store, tasks)
 }
 
+// stdin — общий буферизованный ввод терминала. Создается один раз, чтобы уже считанные в буфер строки
+// не терялись между вызовами read и confirm.
+var stdin = bufio.NewReader(os.Stdin)
+
 // read выполняет чтение команд из терминала до тех пор, пока ввод будет пустым,
 // и возвращает прочитанную команду в виде строки.
 // При вводе пустой строки в терминал выведется подсказка для пользователя.
+// При закрытии ввода возвращается команда exit.
 func read() string {
 	for {
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
+		input, err := stdin.ReadString('\n')
 		input = strings.TrimRight(input, "\r\n")
 
 		if input != "" {
 			return input
 		}
+		if err != nil {
+			return "exit"
+		}
 
 		fmt.Println("To get information about available commands, type help")
 	}
 }
 
+// confirm задает пользователю вопрос и возвращает true, если ответ начинается с y.
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, _ := stdin.ReadString('\n')
+
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
+// confirmTargets находит в хранилище задачи, на которые указывает цель команды, и возвращает их вместе с целью,
+// закрепленной за их ID (см. filemanager.PinTargets): операция изменит именно эти задачи или не изменит ничего.
+// Если задач больше filemanager.ConfirmThreshold, задачи выводятся и у пользователя запрашивается подтверждение;
+// при отказе возвращается false. Ошибки чтения и поиска задач выводятся, и команда не выполняется.
+func (s *storage) confirmTargets(target, action string) (string, []models.Task, bool) {
+	tasks, err := s.store.List()
+	if err != nil {
+		fmt.Println(fmt.Errorf("store.List: %w", err))
+		return "", nil, false
+	}
+	targets, err := filemanager.Targets(tasks, target)
+	if err != nil {
+		fmt.Println(err)
+		return "", nil, false
+	}
+
+	if len(targets) > filemanager.ConfirmThreshold {
+		list, question := filemanager.TargetsSummary(targets, action)
+		if !confirm(list + question) {
+			fmt.Println("Cancelled")
+			return "", nil, false
+		}
+	}
+
+	return filemanager.PinTargets(targets), targets, true
+}
+
+// completeTargets предлагает завершить родительские задачи измененных задач (см. completeParents).
+func (s *storage) completeTargets(targets []models.Task) {
+	for _, task := range targets {
+		s.completeParents(strconv.Itoa(task.Index))
+	}
+}
+
+// completeParents предлагает завершить родительскую задачу, если после изменения задачи ref
+// все подзадачи родителя выполнены. Проверка повторяется вверх по дереву.
+func (s *storage) completeParents(ref string) {
+	for {
+		s.mu.Lock()
+		parent, ok := filemanager.ParentToComplete(s.tasks, ref)
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+
+		if !confirm(fmt.Sprintf("All subtasks of task %d are done. Mark it as done? [y/N]: ", parent.Index)) {
+			return
+		}
+
+		ref = strconv.Itoa(parent.Index)
+		s.mu.Lock()
+		result, err := filemanager.UpdateStatus(s.store, &s.tasks, []string{ref, filemanager.DoneStatuses()[0]})
+		s.mu.Unlock()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(result)
+	}
+}
+
 // splitInput получает на вход считанную команду пользователя в виде строки
 // и разбивает ее на составляющие (команда и аргументы), после чего возвращает их в виде слайса типа строки.
 // Ключевой особенностью является то, что символы, заключенные в кавычки считаются одним аргументом.
@@ -96,6 +240,234 @@ func splitInput(input string) []string {
 	return result
 }
 
+// optional возвращает элемент команды по индексу или пустую строку, если необязательный аргумент не передан.
+func optional(elements []string, i int) string {
+	if i < len(elements) {
+		return elements[i]
+	}
+
+	return ""
+}
+
+// modifiers — модификаторы, которые можно указать в любом месте команд add и update.
+type modifiers struct {
+	// tags — изменения тегов через запятую.
+	tags    string
+	project string
+	parent  string
+	recur   string
+	due     string
+}
+
+// splitModifiers отделяет от аргументов команды модификаторы тегов (+tag добавляет тег, -tag удаляет),
+// проекта (project:name или pro:name, пустое название удаляет проект), родительской задачи (parent:<index>),
+// правила повторения (recur:<rule>) и срока выполнения (due:<date>, фраза с пробелами заключается в кавычки).
+// Возвращает оставшиеся аргументы и найденные модификаторы.
+func splitModifiers(elements []string) ([]string, modifiers) {
+	var (
+		args []string
+		tags []string
+		mods modifiers
+	)
+
+	for _, element := range elements {
+		if name, ok := cutPrefix(element, "project:", "pro:"); ok {
+			mods.project = name
+			if name == "" {
+				mods.project = "none"
+			}
+			continue
+		}
+		if parent, ok := cutPrefix(element, "parent:"); ok {
+			mods.parent = parent
+			continue
+		}
+		if rule, ok := cutPrefix(element, "recur:"); ok {
+			mods.recur = rule
+			continue
+		}
+		if due, ok := cutPrefix(element, "due:"); ok {
+			mods.due = due
+			continue
+		}
+
+		if len(element) > 1 && (element[0] == '+' || element[0] == '-') {
+			tags = append(tags, element)
+		} else {
+			args = append(args, element)
+		}
+	}
+	mods.tags = strings.Join(tags, ",")
+
+	return args, mods
+}
+
+// cutPrefix проверяет, начинается ли аргумент с одного из префиксов без учета регистра,
+// и возвращает значение после префикса без кавычек.
+func cutPrefix(element string, prefixes ...string) (string, bool) {
+	lower := strings.ToLower(element)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return strings.Trim(element[len(prefix):], "\"'"), true
+		}
+	}
+
+	return "", false
+}
+
+// listOptions разбирает необязательные аргументы команд вывода списка задач:
+// статусы (status:name,name), теги (+tag), проект (project:name), ключи сортировки (sort:due,priority
+// или последним аргументом), ключ группировки (group:project), колонки (columns:index,name,due)
+// и формат вывода (format:json).
+func listOptions(args []string) filemanager.ListOptions {
+	var opts filemanager.ListOptions
+	for _, arg := range args {
+		name, isProject := cutPrefix(arg, "project:", "pro:")
+		statuses, isStatus := cutPrefix(arg, "status:")
+		sortKeys, isSort := cutPrefix(arg, "sort:")
+		groupKey, isGroup := cutPrefix(arg, "group:")
+		columns, isColumns := cutPrefix(arg, "columns:")
+		format, isFormat := cutPrefix(arg, "format:")
+		switch {
+		case isProject:
+			opts.Project = name
+		case isStatus:
+			opts.Statuses = append(opts.Statuses, strings.Split(statuses, ",")...)
+		case isSort:
+			opts.Sort = sortKeys
+		case isGroup:
+			opts.GroupBy = groupKey
+		case isColumns:
+			opts.Columns = strings.Split(columns, ",")
+		case isFormat:
+			opts.Format = format
+		case strings.EqualFold(arg, "--all"):
+			opts.All = true
+		case len(arg) > 1 && arg[0] == '+':
+			opts.Tags = append(opts.Tags, arg[1:])
+		default:
+			opts.Sort = arg
+		}
+	}
+
+	return opts
+}
+
+// unquote убирает кавычки, в которые заключен аргумент целиком, например фильтр в команде update.
+func unquote(element string) string {
+	if len(element) > 1 && (element[0] == '"' || element[0] == '\'') && element[len(element)-1] == element[0] {
+		return element[1 : len(element)-1]
+	}
+
+	return element
+}
+
+// withoutLast возвращает часть введенной команды после первого слова без последнего аргумента,
+// например цель команды updatestatus <Target> <Status>.
+func withoutLast(input string, elements []string) string {
+	rest := strings.TrimSpace(afterWords(input, 1))
+	return strings.TrimSpace(strings.TrimSuffix(rest, elements[len(elements)-1]))
+}
+
+// afterWords возвращает часть введенной команды после первых n слов.
+func afterWords(input string, n int) string {
+	rest := strings.TrimSpace(input)
+	for range n {
+		_, rest, _ = strings.Cut(rest, " ")
+		rest = strings.TrimLeft(rest, " ")
+	}
+
+	return rest
+}
+
+// listTerm — параметры вывода в выражении команды list: sort:<keys>, group:<key>, columns:<names>, format:<name> и --all.
+var listTerm = regexp.MustCompile(`(?i)(^|\s)((sort|group|columns|format):(\S+)|--all\b)`)
+
+// cutListTerms возвращает параметры команды list: фильтр, ключи сортировки (sort:<keys>), группировки (group:<key>),
+// колонки (columns:<names>), формат вывода (format:<name>) и признак вывода задач из архива (--all).
+// Извлеченные части заменяются пробелами, чтобы позиции в сообщениях об ошибках фильтра совпадали с введенным выражением.
+func cutListTerms(filter string) filemanager.ListOptions {
+	var opts filemanager.ListOptions
+	opts.Filter = listTerm.ReplaceAllStringFunc(filter, func(match string) string {
+		parts := listTerm.FindStringSubmatch(match)
+		switch strings.ToLower(parts[3]) {
+		case "sort":
+			opts.Sort = parts[4]
+		case "group":
+			opts.GroupBy = parts[4]
+		case "columns":
+			opts.Columns = strings.Split(parts[4], ",")
+		case "format":
+			opts.Format = parts[4]
+		default:
+			opts.All = true
+		}
+		return strings.Repeat(" ", utf8.RuneCountInString(match))
+	})
+
+	return opts
+}
+
+// reportAliases сопоставляет прежним командам вывода списков встроенные отчеты, которые их заменили.
+var reportAliases = map[string]string{
+	"alltasks":        "all",
+	"donetasks":       "done",
+	"notdonetasks":    "notdone",
+	"inprogresstasks": "inprogress",
+}
+
+// report выполняет команду report: вывод отчета (report <name> [<Filters>]), список отчетов (report list),
+// сохранение отчета (report add <name> [<Filter>] [sort:<keys>] [group:<key>] [columns:<names>])
+// и удаление отчета (report delete <name>).
+func (s *storage) report(input string, elements []string) {
+	if len(elements) < 2 {
+		fmt.Println(filemanager.ErrReportNameNotExists)
+		return
+	}
+
+	var (
+		result string
+		err    error
+	)
+	switch strings.ToLower(elements[1]) {
+	case "list":
+		result = filemanager.ReportsList()
+	case "add":
+		if len(elements) < 3 {
+			fmt.Println(filemanager.ErrReportNameNotExists)
+			return
+		}
+		opts := cutListTerms(afterWords(input, 3))
+		result, err = filemanager.SaveReport(s.configPath, config.Report{
+			Name:    elements[2],
+			Filter:  strings.TrimSpace(opts.Filter),
+			Sort:    opts.Sort,
+			GroupBy: opts.GroupBy,
+			Columns: opts.Columns,
+		})
+	case "delete":
+		if len(elements) != 3 {
+			fmt.Println(filemanager.ErrInputElementsCount)
+			return
+		}
+		result, err = filemanager.DeleteReport(s.configPath, elements[2])
+	default:
+		opts := listOptions(elements[2:])
+		var tasks []models.Task
+		tasks, err = s.listed(opts)
+		if err == nil {
+			err = filemanager.RunReport(tasks, elements[1], opts)
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if result != "" {
+		fmt.Println(result)
+	}
+}
+
 // Handle вызывает функцию считывания пользовательского ввода до тех пор, пока не поступит команда Exit.
 // В иных случаях функция вызывает соответствующий метод в зависимости от команды пользователя
 // и выводит результат в терминал.
@@ -108,11 +480,14 @@ func (s *storage) Handle() error {
 
 		switch strings.ToLower(elements[0]) {
 		case "add":
-			if len(elements) != 2 {
+			args, mods := splitModifiers(elements[1:])
+			if len(args) != 1 && len(args) != 2 {
 				fmt.Println(filemanager.ErrInputElementsCount)
 				continue
 			}
-			result, err := filemanager.Add(&s.tasks, elements[1:])
+			s.mu.Lock()
+			result, err := filemanager.Add(s.store, &s.tasks, []string{args[0], mods.due, optional(args, 1), mods.tags, mods.project, mods.parent, mods.recur})
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
 				continue
@@ -120,84 +495,448 @@ func (s *storage) Handle() error {
 			fmt.Println(result)
 
 		case "update":
-			if len(elements) != 4 {
+			args, mods := splitModifiers(elements[1:])
+			if len(args) != 3 && len(args) != 4 {
 				fmt.Println(filemanager.ErrInputElementsCount)
 				continue
 			}
-			result, err := filemanager.Update(&s.tasks, elements[1:])
+			// Фильтр в качестве цели заключается в кавычки: update "project:infra status:open" "<Name>" <Status>.
+			target, targets, ok := s.confirmTargets(unquote(args[0]), "Update")
+			if !ok {
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.Update(s.store, &s.tasks, []string{target, args[1], args[2], mods.due, optional(args, 3), mods.tags, mods.project})
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
 			fmt.Println(result)
+			s.completeTargets(targets)
 
 		case "delete":
+			// Режим удаления передается последним аргументом: delete 3-7,10 cascade.
+			if len(elements) < 2 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			target, mode := strings.TrimSpace(afterWords(input, 1)), ""
+			if last := elements[len(elements)-1]; len(elements) > 2 &&
+				(strings.EqualFold(last, filemanager.DeleteCascade) || strings.EqualFold(last, filemanager.DeletePromote)) {
+				target, mode = withoutLast(input, elements), last
+			}
+			target, _, ok := s.confirmTargets(target, "Delete")
+			if !ok {
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.Delete(s.store, &s.tasks, []string{target, mode})
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "updatestatus", "done":
+			// Статус передается последним аргументом, команда done завершает задачи: done project:infra +sprint12.
+			target, status := strings.TrimSpace(afterWords(input, 1)), filemanager.DoneStatuses()[0]
+			if strings.ToLower(elements[0]) == "updatestatus" {
+				if len(elements) < 3 {
+					fmt.Println(filemanager.ErrInputElementsCount)
+					continue
+				}
+				target, status = withoutLast(input, elements), elements[len(elements)-1]
+			}
+			if target == "" {
+				fmt.Println(filemanager.ErrIndexNotExists)
+				continue
+			}
+			target, targets, ok := s.confirmTargets(target, "Update")
+			if !ok {
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.UpdateStatus(s.store, &s.tasks, []string{target, status})
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+			s.completeTargets(targets)
+
+		case "list":
+			opts := cutListTerms(afterWords(input, 1))
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.ListTasks(tasks, opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "alltasks", "donetasks", "notdonetasks", "inprogresstasks":
+			opts := listOptions(elements[1:])
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.RunReport(tasks, reportAliases[strings.ToLower(elements[0])], opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "report":
+			s.report(input, elements)
+
+		case "reports":
+			fmt.Println(filemanager.ReportsList())
+
+		case "due":
+			if len(elements) < 3 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.SetDue(s.store, &s.tasks, []string{elements[1], strings.Join(elements[2:], " ")})
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "priority":
+			if len(elements) != 3 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.SetPriority(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "show":
 			if len(elements) != 2 {
 				fmt.Println(filemanager.ErrInputElementsCount)
 				continue
 			}
-			result, err := filemanager.Delete(&s.tasks, elements[1:])
+			result, err := filemanager.Show(s.snapshot(), elements[1])
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "note":
+			if len(elements) < 3 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.AddNote(s.store, &s.tasks, []string{elements[1], strings.Join(elements[2:], " ")})
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "edit":
+			if len(elements) != 2 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			// Редактор может быть открыт долго, поэтому задачи редактируются в копии без блокировки,
+			// а после закрытия редактора перечитываются из хранилища.
+			tasks := s.snapshot()
+			s.editing.Store(true)
+			result, err := filemanager.Edit(s.store, &tasks, elements[1:])
+			_, reloadErr := s.reload()
+			s.editing.Store(false)
+			if reloadErr != nil {
+				fmt.Println(reloadErr)
+			}
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+			s.completeParents(elements[1])
+
+		case "recur":
+			if len(elements) != 3 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.SetRecurrence(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "overduetasks":
+			opts := listOptions(elements[1:])
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.OverdueTasks(tasks, opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "duetodaytasks":
+			opts := listOptions(elements[1:])
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.DueTodayTasks(tasks, opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "duethisweektasks":
+			opts := listOptions(elements[1:])
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.DueThisWeekTasks(tasks, opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "link":
+			if len(elements) != 3 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.Link(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
 			fmt.Println(result)
 
-		case "updatestatus":
+		case "unlink":
 			if len(elements) != 3 {
 				fmt.Println(filemanager.ErrInputElementsCount)
 				continue
 			}
-			result, err := filemanager.UpdateStatus(&s.tasks, elements[1:])
+			s.mu.Lock()
+			result, err := filemanager.Unlink(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
 			fmt.Println(result)
 
-		case "alltasks":
-			err := filemanager.AllTasks(&s.tasks)
+		case "archive":
+			// Аргументом передается номер или префикс ID задачи, список номеров либо фильтр.
+			target, _, ok := s.confirmTargets(strings.TrimSpace(afterWords(input, 1)), "Archive")
+			if !ok {
+				continue
+			}
+			args := []string{"", target}
+			if filemanager.IsTaskList(target) {
+				args = []string{target, ""}
+			}
+			s.mu.Lock()
+			result, err := filemanager.Archive(s.store, &s.tasks, args)
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
+				continue
 			}
+			fmt.Println(result)
 
-		case "donetasks":
-			err := filemanager.DoneTasks(&s.tasks)
+		case "restore":
+			if len(elements) != 2 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			s.mu.Lock()
+			result, err := filemanager.Restore(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
+				continue
 			}
+			fmt.Println(result)
 
-		case "notdonetasks":
-			err := filemanager.NotDoneTasks(&s.tasks)
+		case "trash":
+			err := filemanager.TrashTasks(s.store, listOptions(elements[1:]))
 			if err != nil {
 				fmt.Println(err)
 			}
 
-		case "inprogresstasks":
-			err := filemanager.InProgressTasks(&s.tasks)
+		case "empty-trash", "emptytrash":
+			s.mu.Lock()
+			result, err := filemanager.EmptyTrash(s.store, &s.tasks, nil)
+			s.mu.Unlock()
 			if err != nil {
 				fmt.Println(err)
+				continue
 			}
+			fmt.Println(result)
+
+		case "history":
+			if len(elements) != 2 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			result, err := filemanager.History(s.store, s.snapshot(), elements[1])
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "log":
+			// Начало периода можно передать как log yesterday или log --since yesterday.
+			since := strings.TrimPrefix(strings.TrimPrefix(afterWords(input, 1), "--since"), "=")
+			err := filemanager.Log(s.store, strings.TrimSpace(since))
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "undo", "redo":
+			if len(elements) > 2 {
+				fmt.Println(filemanager.ErrInputElementsCount)
+				continue
+			}
+			step := filemanager.Undo
+			if strings.ToLower(elements[0]) == "redo" {
+				step = filemanager.Redo
+			}
+			s.mu.Lock()
+			result, err := step(s.store, &s.tasks, elements[1:])
+			s.mu.Unlock()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(result)
+
+		case "readytasks", "ready":
+			opts := listOptions(elements[1:])
+			tasks, err := s.listed(opts)
+			if err == nil {
+				err = filemanager.ReadyTasks(tasks, opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "search":
+			// Формат вывода можно указать среди слов запроса: search deploy format:json.
+			opts := cutListTerms(afterWords(input, 1))
+			err := filemanager.SearchTasks(s.snapshot(), opts.Filter, opts.Format)
+			if err != nil {
+				fmt.Println(err)
+			}
+
+		case "tags":
+			filemanager.Tags(s.snapshot())
+
+		case "projects":
+			filemanager.Projects(s.snapshot())
+
+		case "where":
+			fmt.Println(s.store.Where())
 
 		case "help":
-			fmt.Println(`	Add "<Task name>"
-	Update <Task Index> "<New Task Name>" <New Task Status>
-		Task Statuses:
-			0 - Not started
-			1 - In progress
-			2 - Done
-	Delete <Task Index>
-	UpdateStatus <Task Index> <New Task Status>
-		Task Statuses:
-			0 - Not started
-			1 - In progress
-			2 - Done
-	AllTasks
-	DoneTasks
-	NotDoneTasks
-	InProgressTasks
+			statuses := filemanager.StatusesHelp()
+			fmt.Printf(`	Add "<Task name>" [<Priority>] [+<Tag> ...] [project:<Project>] [parent:<Parent Task Index or ID prefix>] [recur:<Rule>]
+		[due:<Due Date>], a due date with spaces is quoted: due:"next fri"
+	Update <Tasks> "<New Task Name>" <New Task Status> [<Priority>] [+<Tag> ...] [-<Tag> ...] [project:<Project>]
+		[due:<Due Date>]
+		Task Statuses: %s
+		Tasks: a task index or ID prefix, indexes and ranges (3-7,10) or a filter, in Update the filter is quoted
+		if more than %d tasks are changed (confirm_threshold in the config), they are shown and a confirmation is asked
+	Delete <Tasks> [cascade|promote]
+		moves the task to the trash, see Trash and Restore
+		cascade - delete the task with all its subtasks
+		promote - delete only the task and move its subtasks to its parent
+	UpdateStatus <Tasks> <New Task Status>
+		Task Statuses: %s
+	Done <Tasks> (e.g. done project:infra +sprint12)
+	Priority <Task Index or ID prefix> <Priority>
+		Priorities: none, low (L), medium (M), high (H), critical (C)
+	Due <Task Index or ID prefix> <Due Date>
+		Due Dates: 2025-12-31, 2025-12-31 18:00, today, tomorrow, eod, eow, eom, fri, next fri,
+			next week, next month, in 3 days, in 2 weeks, none (removes the due date)
+	Show <Task Index or ID prefix>
+	Note <Task Index or ID prefix> <Note text>
+	Edit <Task Index or ID prefix> (opens the task in $EDITOR)
+	Recur <Task Index or ID prefix> <Rule>
+		Rules: daily, weekly, weekly:mon,fri, monthly, monthly:15, after:3d (3 days after completion), none
+		when a recurring task is done, its next occurrence is added with the next due date
+	History <Task Index or ID prefix>
+		shows who changed the task, when and what was changed, also for deleted tasks
+	Log [--since <Date>]
+		shows the changes of all tasks, Dates: yesterday, today, 2025-12-31, 2025-12-31 18:00
+	Archive <Tasks>
+		moves the tasks with their subtasks to the archive, e.g. archive status:closed completed<"30 days ago"
+		archived tasks are hidden from lists, --all shows them
+	Restore <Task Index or ID prefix>
+		returns the task with its subtasks from the archive or the trash
+	Trash [sort:<Sort Key>] [columns:<Column>[,<Column>]]
+		shows deleted tasks, recently deleted first
+	Empty-Trash
+		deletes the tasks in the trash permanently
+	Undo [<Number of Operations>]
+		reverts the last changes of tasks, the changes are kept in a journal next to the tasks file
+	Redo [<Number of Operations>]
+		applies the undone changes again, a new change clears the changes to redo
+	Link <Task Index or ID prefix> <Blocking Task Index or ID prefix>
+		the task cannot be started until the blocking task is done
+	Unlink <Task Index or ID prefix> <Blocking Task Index or ID prefix>
+	List [<Filter>] [sort:<Sort Key>[,<Sort Key>]] [group:<Group Key>] [columns:<Column>[,<Column>]] [format:<Format>] [--all]
+		Filter: status:open priority>=high +urgent due.before:fri name~"deploy" or project:infra
+		Fields: status (a status, new, open, closed, started), priority, project, tag, name, description, id, index,
+			urgency, due, created, updated, started, completed, archived, deleted
+			(dates: today, fri, 2025-12-31, "30 days ago", none, any)
+		Operators: : = != < <= > >= ~ (regular expression), and, or, not, ( )
+			conditions separated by spaces are joined with and, a bare word searches in task names
+	Report <Report Name> [<Filters>] [<Sort Keys>] [group:<Group Key>] [columns:<Columns>]
+		Built-in Reports: all, next, done, notdone, inprogress, weekly (AllTasks, DoneTasks, NotDoneTasks
+			and InProgressTasks run the all, done, notdone and inprogress reports)
+	Report add <Report Name> [<Filter>] [sort:<Sort Keys>] [group:<Group Key>] [columns:<Columns>]
+		saves the report to the config file, a saved report replaces a built-in report with the same name
+	Report delete <Report Name>
+	Reports (or Report list)
+	OverdueTasks [<Filters>] [<Sort Keys>] [group:<Group Key>]
+	DueTodayTasks [<Filters>] [<Sort Keys>] [group:<Group Key>]
+	DueThisWeekTasks [<Filters>] [<Sort Keys>] [group:<Group Key>]
+	ReadyTasks [<Filters>] [<Sort Keys>] [group:<Group Key>] (not started and not blocked)
+		Filters: status:<Status>[,<Status>], +<Tag>, project:<Project> (includes subprojects)
+		Sort Keys: urgency (default), priority, due, created, updated, started, completed, archived, deleted,
+			name, status, index
+			several keys are separated by commas, a direction is set by :asc or :desc (due,priority:asc)
+		Group Keys: status, project, tag, due (by week)
+		Columns: index, id, name, status, priority, urgency, created, updated, completed, started, blocked,
+			subtasks, project, tags, recur, due, archived, deleted (all by default)
+		--all - also shows archived tasks
+		format:<Format> - text (default), json, jsonl, csv, tsv, yaml, markdown (md)
+	Search <Terms> [format:<Format>]
+		searches names, tags, descriptions and notes, ignores case and tolerates typos
+	Tags
+	Projects
+	Where
 	Help
-	Exit`)
+	Exit
+`, statuses, filemanager.ConfirmThreshold, statuses)
 		case "exit":
 			return nil
 		default:
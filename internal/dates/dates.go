@@ -0,0 +1,190 @@
+// Package dates реализует разбор дат, которые пользователь вводит в командах:
+// даты в формате ISO и фразы вида "tomorrow", "fri", "in 3 days", "30 days ago", "next month", "eod".
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate error = errors.New("an invalid date was passed")
+
+// Форматы, в которых принимаются точные даты.
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Parse преобразует строку пользователя в момент времени относительно now.
+// Если в строке указан только день, возвращается конец этого дня (23:59:59),
+// чтобы задача со сроком "сегодня" не считалась просроченной до окончания дня.
+func Parse(input string, now time.Time) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	// Точные даты разбираются до приведения к нижнему регистру: в RFC 3339 буквы T и Z заглавные.
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, input, now.Location())
+		if err == nil {
+			if layout == "2006-01-02" {
+				return EndOfDay(t), nil
+			}
+			return t, nil
+		}
+	}
+
+	input = strings.ToLower(input)
+	today := EndOfDay(now)
+	switch input {
+	case "now":
+		return now, nil
+	case "today", "eod":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eow":
+		return EndOfWeek(now), nil
+	case "eom":
+		return time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location()), nil
+	case "next week":
+		return nextWeekday(today, time.Monday), nil
+	case "next month":
+		return time.Date(now.Year(), now.Month()+1, 1, 23, 59, 59, 0, now.Location()), nil
+	case "next year":
+		return time.Date(now.Year()+1, time.January, 1, 23, 59, 59, 0, now.Location()), nil
+	}
+
+	if day, ok := weekdays[input]; ok {
+		return upcomingWeekday(today, day), nil
+	}
+
+	if name, ok := strings.CutPrefix(input, "next "); ok {
+		if day, ok := weekdays[name]; ok {
+			return upcomingWeekday(today, day).AddDate(0, 0, 7), nil
+		}
+	}
+
+	if offset, ok := strings.CutPrefix(input, "in "); ok {
+		return parseOffset(offset, now, 1)
+	}
+	if offset, ok := strings.CutSuffix(input, " ago"); ok {
+		return parseOffset(offset, now, -1)
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, input)
+}
+
+// parseOffset разбирает смещение вида "3 days", "2 weeks", "1 month", "4 hours", "3d", "2mo".
+// Месяцы записываются только как mo или month, чтобы "30m" не принималось за 30 месяцев.
+// sign задает направление смещения: 1 — в будущее, -1 — в прошлое.
+func parseOffset(offset string, now time.Time, sign int) (time.Time, error) {
+	fields := strings.Fields(offset)
+	if len(fields) == 1 {
+		// Короткая запись: число и единица измерения без пробела, например "3d".
+		i := strings.IndexFunc(fields[0], func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, offset)
+		}
+		fields = []string{fields[0][:i], fields[0][i:]}
+	}
+	if len(fields) != 2 {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, offset)
+	}
+
+	// Знак сдвига задается словами "in" и "ago", поэтому число записывается только цифрами и больше нуля.
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 || strings.ContainsAny(fields[0][:1], "+-") {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, offset)
+	}
+	n *= sign
+
+	today := EndOfDay(now)
+	switch strings.TrimSuffix(fields[1], "s") {
+	case "h", "hour":
+		return now.Add(time.Duration(n) * time.Hour), nil
+	case "d", "day":
+		return today.AddDate(0, 0, n), nil
+	case "w", "week":
+		return today.AddDate(0, 0, 7*n), nil
+	case "mo", "month":
+		return addMonths(today, n), nil
+	case "y", "year":
+		return addMonths(today, 12*n), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, offset)
+}
+
+// addMonths сдвигает дату на n месяцев. Если в целевом месяце нет такого числа, возвращается его последний день:
+// 31 января + 1 месяц = 28 или 29 февраля, а не начало марта, как у time.AddDate.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	return time.Date(first.Year(), first.Month(), min(t.Day(), last), t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
+
+// ParseWeekday возвращает день недели по полному или короткому английскому названию (mon, friday).
+func ParseWeekday(name string) (time.Weekday, bool) {
+	day, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+	return day, ok
+}
+
+// upcomingWeekday возвращает ближайший указанный день недели, начиная с дня from включительно.
+func upcomingWeekday(from time.Time, day time.Weekday) time.Time {
+	diff := (int(day) - int(from.Weekday()) + 7) % 7
+	return from.AddDate(0, 0, diff)
+}
+
+// nextWeekday возвращает указанный день недели строго после дня from.
+func nextWeekday(from time.Time, day time.Weekday) time.Time {
+	diff := (int(day) - int(from.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return from.AddDate(0, 0, diff)
+}
+
+// EndOfDay возвращает последнюю секунду дня, к которому относится t.
+func EndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// EndOfWeek возвращает последнюю секунду воскресенья недели, к которой относится t.
+func EndOfWeek(t time.Time) time.Time {
+	return upcomingWeekday(EndOfDay(t), time.Sunday)
+}
+
+// SameDay сообщает, относятся ли a и b к одному календарному дню в часовом поясе a.
+func SameDay(a, b time.Time) bool {
+	b = b.In(a.Location())
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// Format выводит дату без времени, если она указывает на конец дня, и с временем в остальных случаях.
+func Format(t time.Time) string {
+	t = t.Local()
+	if t.Hour() == 23 && t.Minute() == 59 && t.Second() == 59 {
+		return t.Format("2006-01-02")
+	}
+
+	return t.Format("2006-01-02 15:04")
+}
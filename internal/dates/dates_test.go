@@ -0,0 +1,71 @@
+package dates
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Суббота, 31 января: удобно проверять дни недели и переход через короткий месяц.
+	now := time.Date(2026, time.January, 31, 10, 30, 0, 0, time.UTC)
+	endOfDay := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 23, 59, 59, 0, time.UTC)
+	}
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2025-12-31", endOfDay(2025, time.December, 31)},
+		{"2025-12-31 18:00", time.Date(2025, time.December, 31, 18, 0, 0, 0, time.UTC)},
+		{"2025-12-31T18:00", time.Date(2025, time.December, 31, 18, 0, 0, 0, time.UTC)},
+		{"now", now},
+		{"today", endOfDay(2026, time.January, 31)},
+		{" Tomorrow ", endOfDay(2026, time.February, 1)},
+		{"yesterday", endOfDay(2026, time.January, 30)},
+		{"eom", endOfDay(2026, time.January, 31)},
+		{"eow", endOfDay(2026, time.February, 1)},
+		{"next week", endOfDay(2026, time.February, 2)},
+		{"next month", endOfDay(2026, time.February, 1)},
+		{"next year", endOfDay(2027, time.January, 1)},
+		{"sat", endOfDay(2026, time.January, 31)},
+		{"fri", endOfDay(2026, time.February, 6)},
+		{"next fri", endOfDay(2026, time.February, 13)},
+		{"in 4 hours", time.Date(2026, time.January, 31, 14, 30, 0, 0, time.UTC)},
+		{"in 3 days", endOfDay(2026, time.February, 3)},
+		{"in 3d", endOfDay(2026, time.February, 3)},
+		{"in 2 weeks", endOfDay(2026, time.February, 14)},
+		{"in 1 month", endOfDay(2026, time.February, 28)},
+		{"in 13mo", endOfDay(2027, time.February, 28)},
+		{"in 1 year", endOfDay(2027, time.January, 31)},
+		{"2 days ago", endOfDay(2026, time.January, 29)},
+		{"1 month ago", endOfDay(2025, time.December, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2026, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	for _, input := range []string{"", "someday", "in 30m", "in x days", "in 3", "3 days", "2025-13-01",
+		"in -3 days", "in 0 days", "in +3 days", "-2 days ago", "in -3d"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := Parse(input, now)
+			if !errors.Is(err, ErrInvalidDate) {
+				t.Errorf("Parse(%q) error = %v, want %v", input, err, ErrInvalidDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/atomicfile"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/query"
+)
+
+const (
+	archiveSuffix = ".archive.json"
+)
+
+var ErrArchiveTargetNotExists error = errors.New("task index or filter is missing from the passed arguments")
+
+// isArchived сообщает, что задача находится в архиве или в корзине.
+func isArchived(task models.Task) bool {
+	return !task.ArchivedAt.IsZero() || !task.DeletedAt.IsZero()
+}
+
+// archivePath возвращает путь к файлу архива файла задач.
+func archivePath(path string) string {
+	return path + archiveSuffix
+}
+
+// readArchive считывает задачи из файла архива. Если файла нет, архив пуст.
+// Файл архива имеет тот же формат, что и файл задач, счетчик номеров в нем не используется.
+func readArchive(path string) ([]models.Task, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	list, err := readTasks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Tasks, nil
+}
+
+// writeArchive атомарно записывает задачи в файл архива.
+func writeArchive(path string, tasks []models.Task) error {
+	data, err := encodeFile(TaskList{Tasks: tasks})
+	if err != nil {
+		return fmt.Errorf("encodeFile: %w", err)
+	}
+
+	err = atomicfile.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// moveToArchive переносит задачи с ID из ids в архив и отмечает их функцией mark.
+// Возвращает количество перенесенных задач.
+func moveToArchive(list *TaskList, ids map[string]bool, mark func(task *models.Task)) int {
+	moved := 0
+	list.Tasks = slices.DeleteFunc(list.Tasks, func(task models.Task) bool {
+		if !ids[task.ID] {
+			return false
+		}
+		mark(&task)
+		list.Archived = append(list.Archived, task)
+		moved++
+		return true
+	})
+
+	return moved
+}
+
+// withDescendants возвращает ID задач вместе с ID всех их подзадач.
+func withDescendants(tasks []models.Task, matched []models.Task) map[string]bool {
+	ids := make(map[string]bool)
+	for _, task := range matched {
+		ids[task.ID] = true
+		for _, subtask := range descendants(tasks, task.ID) {
+			ids[subtask.ID] = true
+		}
+	}
+
+	return ids
+}
+
+// Archive реализует перенос задач в архив вместе с их подзадачами. Первым аргументом передается номер
+// или префикс ID задачи либо список номеров и диапазонов (3-7,10), вторым — фильтр (см. пакет query), например: status:closed completed<"30 days ago".
+// Задачи в архиве не выводятся в списках и хранятся в отдельном файле. После чего возвращает сообщение
+// о результате действия или ошибку.
+func Archive(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	ref, filter := optionalElement(elements, 0), optionalElement(elements, 1)
+	if ref == "" && filter == "" {
+		return "", ErrArchiveTargetNotExists
+	}
+
+	var q *query.Query
+	if ref == "" {
+		var err error
+		q, err = query.Parse(filter, queryEnv())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	archived := 0
+	err := store.Modify(func(list *TaskList) error {
+		var matched []models.Task
+		if ref != "" {
+			positions, err := resolveTargets(list.Tasks, ref)
+			if err != nil {
+				return err
+			}
+			for _, i := range positions {
+				matched = append(matched, list.Tasks[i])
+			}
+		} else {
+			for _, task := range list.Tasks {
+				if q.Match(task) {
+					matched = append(matched, task)
+				}
+			}
+		}
+
+		at := timeNow()
+		archived = moveToArchive(list, withDescendants(list.Tasks, matched), func(task *models.Task) {
+			task.ArchivedAt = at
+		})
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return notFoundMessage(ref), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	switch archived {
+	case 0:
+		return "No tasks to archive", nil
+	case 1:
+		return "1 task archived", nil
+	}
+	return fmt.Sprintf("%d tasks archived", archived), nil
+}
+
+// Restore реализует возврат задачи из архива или корзины по номеру или префиксу ID вместе с ее подзадачами,
+// которые находятся в архиве или корзине. После чего возвращает сообщение о результате действия или ошибку.
+func Restore(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	ref := optionalElement(elements, 0)
+	if ref == "" {
+		return "", ErrIndexNotExists
+	}
+
+	var restored models.Task
+	subtasks := 0
+	err := store.Modify(func(list *TaskList) error {
+		i, err := resolveRef(list.Archived, ref)
+		if err != nil {
+			return err
+		}
+		restored = list.Archived[i]
+
+		ids := withDescendants(list.Archived, []models.Task{restored})
+		subtasks = len(ids) - 1
+		list.Archived = slices.DeleteFunc(list.Archived, func(task models.Task) bool {
+			if !ids[task.ID] {
+				return false
+			}
+			task.ArchivedAt, task.DeletedAt = time.Time{}, time.Time{}
+			list.Tasks = insertTask(list.Tasks, task)
+			return true
+		})
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found in the archive or trash", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	if subtasks > 0 {
+		return fmt.Sprintf("Task %d restored with %d subtasks", restored.Index, subtasks), nil
+	}
+	return fmt.Sprintf("Task %d restored", restored.Index), nil
+}
+
+// WithArchived возвращает задачи вместе с задачами из архива для списков с флагом --all.
+// Задачи из корзины не добавляются.
+func WithArchived(store Store, tasks []models.Task) ([]models.Task, error) {
+	archived, err := store.ListArchived()
+	if err != nil {
+		return nil, fmt.Errorf("store.ListArchived: %w", err)
+	}
+
+	for _, task := range archived {
+		if task.DeletedAt.IsZero() {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// TrashTasks передает в функцию для вывода в терминал список задач в корзине, по умолчанию — недавно удаленные первыми.
+func TrashTasks(store Store, opts ListOptions) error {
+	archived, err := store.ListArchived()
+	if err != nil {
+		return fmt.Errorf("store.ListArchived: %w", err)
+	}
+
+	var trash []models.Task
+	for _, task := range archived {
+		if !task.DeletedAt.IsZero() {
+			trash = append(trash, task)
+		}
+	}
+	format, err := parseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	if len(trash) == 0 && format == FormatText {
+		fmt.Println("Trash is empty")
+		return nil
+	}
+
+	if opts.Sort == "" {
+		opts.Sort = "deleted:desc"
+	}
+	err = printTasks(trash, trash, opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
+
+// EmptyTrash реализует окончательное удаление задач из корзины. Ссылки на удаленные задачи
+// убираются из зависимостей остальных задач. После чего возвращает сообщение о результате действия или ошибку.
+func EmptyTrash(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	deleted := 0
+	err := store.Modify(func(list *TaskList) error {
+		removed := make(map[string]bool)
+		list.Archived = slices.DeleteFunc(list.Archived, func(task models.Task) bool {
+			if task.DeletedAt.IsZero() {
+				return false
+			}
+			removed[task.ID] = true
+			return true
+		})
+
+		deleted = len(removed)
+		removeDependencies(list.Tasks, removed)
+		removeDependencies(list.Archived, removed)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	if deleted == 0 {
+		return "Trash is empty", nil
+	}
+	return fmt.Sprintf("Trash emptied, %d tasks deleted permanently", deleted), nil
+}
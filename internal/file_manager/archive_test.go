@@ -0,0 +1,161 @@
+package filemanager
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestArchiveAndRestore(t *testing.T) {
+	type step struct {
+		op       func(store Store, tasks *[]models.Task, elements []string) (string, error)
+		elements []string
+		want     string
+	}
+
+	tests := []struct {
+		name         string
+		steps        []step
+		wantTasks    []string
+		wantArchived []string
+		wantTrash    []string
+	}{
+		{
+			name:         "archive with subtasks",
+			steps:        []step{{op: Archive, elements: []string{"1", ""}, want: "2 tasks archived"}},
+			wantTasks:    []string{"c"},
+			wantArchived: []string{"a", "b"},
+		},
+		{
+			name:         "archive by filter",
+			steps:        []step{{op: Archive, elements: []string{"", "status:done"}, want: "1 task archived"}},
+			wantTasks:    []string{"a", "b"},
+			wantArchived: []string{"c"},
+		},
+		{
+			name:      "nothing to archive",
+			steps:     []step{{op: Archive, elements: []string{"", "+missing"}, want: "No tasks to archive"}},
+			wantTasks: []string{"a", "b", "c"},
+		},
+		{
+			name: "restore with subtasks",
+			steps: []step{
+				{op: Archive, elements: []string{"1", ""}, want: "2 tasks archived"},
+				{op: Restore, elements: []string{"1"}, want: "Task 1 restored with 1 subtasks"},
+			},
+			wantTasks: []string{"a", "b", "c"},
+		},
+		{
+			name: "restore a subtask",
+			steps: []step{
+				{op: Archive, elements: []string{"1", ""}, want: "2 tasks archived"},
+				{op: Restore, elements: []string{"2"}, want: "Task 2 restored"},
+			},
+			wantTasks:    []string{"b", "c"},
+			wantArchived: []string{"a"},
+		},
+		{
+			name:      "restore a task that is not archived",
+			steps:     []step{{op: Restore, elements: []string{"3"}, want: "Task not found in the archive or trash"}},
+			wantTasks: []string{"a", "b", "c"},
+		},
+		{
+			name: "delete to trash and restore",
+			steps: []step{
+				{op: Delete, elements: []string{"3"}, want: "Task moved to trash"},
+				{op: Restore, elements: []string{"3"}, want: "Task 3 restored"},
+			},
+			wantTasks: []string{"a", "b", "c"},
+		},
+		{
+			name: "empty trash",
+			steps: []step{
+				{op: Archive, elements: []string{"1", ""}, want: "2 tasks archived"},
+				{op: Delete, elements: []string{"3"}, want: "Task moved to trash"},
+				{op: EmptyTrash, want: "Trash emptied, 1 tasks deleted permanently"},
+				{op: EmptyTrash, want: "Trash is empty"},
+			},
+			wantArchived: []string{"a", "b"},
+		},
+	}
+
+	for storeName, newStore := range testStores() {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				store := newStore(t)
+				addTasks(t, store, "a", "b", "c")
+				// Задача b — подзадача a, задача c выполнена.
+				err := store.Modify(func(list *TaskList) error {
+					list.Tasks[1].ParentID = list.Tasks[0].ID
+					list.Tasks[2].Status = models.TaskStatus(DoneStatuses()[0])
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("store.Modify: %v", err)
+				}
+
+				var tasks []models.Task
+				for i, s := range tt.steps {
+					got, err := s.op(store, &tasks, s.elements)
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					if got != s.want {
+						t.Fatalf("step %d: message = %q, want %q", i, got, s.want)
+					}
+				}
+
+				archived, err := store.ListArchived()
+				if err != nil {
+					t.Fatalf("store.ListArchived: %v", err)
+				}
+				var gotArchived, gotTrash []string
+				for _, task := range archived {
+					if task.DeletedAt.IsZero() {
+						gotArchived = append(gotArchived, task.Name)
+					} else {
+						gotTrash = append(gotTrash, task.Name)
+					}
+				}
+
+				if got := taskNames(t, store); !slices.Equal(got, tt.wantTasks) {
+					t.Errorf("tasks = %v, want %v", got, tt.wantTasks)
+				}
+				if !slices.Equal(gotArchived, tt.wantArchived) {
+					t.Errorf("archived tasks = %v, want %v", gotArchived, tt.wantArchived)
+				}
+				if !slices.Equal(gotTrash, tt.wantTrash) {
+					t.Errorf("tasks in the trash = %v, want %v", gotTrash, tt.wantTrash)
+				}
+			})
+		}
+	}
+}
+
+func TestWithArchivedSkipsTrash(t *testing.T) {
+	store := NewMemoryStore()
+	addTasks(t, store, "a", "b", "c")
+
+	var tasks []models.Task
+	_, err := Archive(store, &tasks, []string{"1", ""})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	_, err = Delete(store, &tasks, []string{"2"})
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	all, err := WithArchived(store, tasks)
+	if err != nil {
+		t.Fatalf("WithArchived: %v", err)
+	}
+	var names []string
+	for _, task := range all {
+		names = append(names, task.Name)
+	}
+	if want := []string{"c", "a"}; !slices.Equal(names, want) {
+		t.Errorf("tasks with archived = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,441 @@
+package filemanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	auditSuffix = ".audit.jsonl"
+	// auditValueLength — длина значения поля, которое выводится в истории изменений.
+	auditValueLength = 60
+)
+
+// Режимы работы приложения, которые записываются в журнал аудита.
+const (
+	ModeREPL = "repl"
+	ModeFlag = "flag"
+)
+
+// Действия с задачами в журнале аудита.
+const (
+	ActionAdded    = "added"
+	ActionUpdated  = "updated"
+	ActionArchived = "archived"
+	ActionTrashed  = "trashed"
+	ActionRestored = "restored"
+	// ActionDeleted — окончательное удаление задачи, например при очистке корзины.
+	ActionDeleted = "deleted"
+)
+
+var ErrInvalidSince error = errors.New("an invalid start date of the log was passed")
+
+// appMode — режим работы приложения, в котором вносятся изменения.
+var appMode string
+
+// SetMode задает режим работы приложения (ModeREPL или ModeFlag) для записей журнала аудита.
+func SetMode(mode string) {
+	appMode = mode
+}
+
+// FieldChange — изменение поля задачи. Значения записываются в виде для чтения человеком, пустая строка
+// означает, что поле не заполнено.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// AuditEntry — запись журнала аудита об изменении одной задачи: когда, кем, на каком компьютере
+// и в каком режиме она изменена и какие поля изменились. Via указывает, что изменение внесено командой undo или redo.
+type AuditEntry struct {
+	At     time.Time     `json:"at"`
+	User   string        `json:"user"`
+	Host   string        `json:"host"`
+	Mode   string        `json:"mode,omitempty"`
+	Action string        `json:"action"`
+	Via    string        `json:"via,omitempty"`
+	TaskID string        `json:"task_id"`
+	Index  int           `json:"index"`
+	Name   string        `json:"name"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// auditFields — поля задачи, изменения которых записываются в журнал аудита. Заметки записываются отдельно.
+var auditFields = []struct {
+	name  string
+	value func(task models.Task) string
+}{
+	{"name", func(task models.Task) string { return task.Name }},
+	{"status", func(task models.Task) string { return string(task.Status) }},
+	{"priority", func(task models.Task) string {
+		if task.Priority == models.PriorityNone {
+			return ""
+		}
+		return priorityName(task.Priority)
+	}},
+	{"due", func(task models.Task) string { return auditTime(task.Due) }},
+	{"started", func(task models.Task) string { return auditTime(task.StartedAt) }},
+	{"completed", func(task models.Task) string { return auditTime(task.CompletedAt) }},
+	{"tags", func(task models.Task) string { return strings.Join(task.Tags, ",") }},
+	{"project", func(task models.Task) string { return task.Project }},
+	{"parent", func(task models.Task) string { return task.ParentID }},
+	{"depends_on", func(task models.Task) string { return strings.Join(task.DependsOn, ",") }},
+	{"recur", func(task models.Task) string { return task.Recur }},
+	{"description", func(task models.Task) string { return task.Description }},
+	{"archived", func(task models.Task) string { return auditTime(task.ArchivedAt) }},
+	{"deleted", func(task models.Task) string { return auditTime(task.DeletedAt) }},
+}
+
+// auditTime записывает момент времени в формате RFC 3339 или пустую строку, если время не задано.
+func auditTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// identity возвращает имя пользователя ОС и имя компьютера. Они определяются один раз за запуск.
+var identity = sync.OnceValues(func() (string, string) {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	} else if name == "" {
+		name = os.Getenv("USERNAME")
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return name, host
+})
+
+// fieldChanges возвращает изменения полей задачи. У добавленной задачи перечисляются заполненные поля,
+// у удаленной изменения не записываются.
+func fieldChanges(change TaskChange) []FieldChange {
+	if change.After == nil {
+		return nil
+	}
+
+	var before models.Task
+	if change.Before != nil {
+		before = *change.Before
+	}
+	after := *change.After
+
+	var fields []FieldChange
+	for _, field := range auditFields {
+		old, next := field.value(before), field.value(after)
+		if old != next {
+			fields = append(fields, FieldChange{Field: field.name, Old: old, New: next})
+		}
+	}
+	// Заметки только добавляются, поэтому записываются только новые заметки.
+	for _, note := range after.Notes[min(len(before.Notes), len(after.Notes)):] {
+		fields = append(fields, FieldChange{Field: "note", New: note.Text})
+	}
+
+	return fields
+}
+
+// auditEntries превращает изменения задач в записи журнала аудита.
+func auditEntries(changes []TaskChange, at time.Time, via string) []AuditEntry {
+	name, host := identity()
+
+	entries := make([]AuditEntry, 0, len(changes))
+	for _, change := range changes {
+		entry := AuditEntry{
+			At:     at,
+			User:   name,
+			Host:   host,
+			Mode:   appMode,
+			Via:    via,
+			Fields: fieldChanges(change),
+		}
+
+		task := change.After
+		if task == nil {
+			task = change.Before
+		}
+		entry.Action = changeAction(change)
+		entry.TaskID, entry.Index, entry.Name = task.ID, task.Index, task.Name
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// auditPath возвращает путь к журналу аудита файла задач.
+func auditPath(path string) string {
+	return path + auditSuffix
+}
+
+// auditSize возвращает размер журнала аудита или 0, если журнала еще нет.
+func auditSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("os.Stat: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// truncateAudit удаляет из журнала аудита записи, добавленные после того, как его размер был равен size.
+// Используется только для отката записи, изменение которой не удалось сохранить.
+func truncateAudit(path string, size int64) error {
+	err := os.Truncate(path, size)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Truncate: %w", err)
+	}
+
+	return nil
+}
+
+// appendAudit дописывает записи в конец журнала аудита, по одной записи в строке. Журнал только дополняется.
+func appendAudit(path string, entries []AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+
+	return file.Close()
+}
+
+// readAudit считывает записи журнала аудита не раньше since. Если журнала нет, возвращается пустой список.
+// Строка, которую не удалось разобрать (например, недописанная при сбое), пропускается.
+func readAudit(path string, since time.Time) ([]AuditEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	return filterAudit(entries, since), nil
+}
+
+// filterAudit возвращает записи не раньше since.
+func filterAudit(entries []AuditEntry, since time.Time) []AuditEntry {
+	var result []AuditEntry
+	for _, entry := range entries {
+		if !entry.At.Before(since) {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// auditValue выводит значение поля в одну строку и сокращает длинные значения.
+func auditValue(field, value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return dates.Format(t)
+	}
+	if field == "parent" || field == "depends_on" {
+		ids := strings.Split(value, ",")
+		for i, id := range ids {
+			ids[i] = ShortID(models.Task{ID: id})
+		}
+		value = strings.Join(ids, ",")
+	}
+
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > auditValueLength {
+		value = string(runes[:auditValueLength]) + "…"
+	}
+
+	return fmt.Sprintf("%q", value)
+}
+
+// writeAuditEntry выводит запись журнала аудита и изменения полей с отступом. Если withTask, в строку
+// добавляется номер и название задачи.
+func writeAuditEntry(b *strings.Builder, entry AuditEntry, withTask bool) {
+	b.WriteString(fmt.Sprintf("%s  %s", entry.At.Local().Format(time.DateTime), entry.Action))
+	if withTask {
+		b.WriteString(fmt.Sprintf(" task %d %q", entry.Index, entry.Name))
+	}
+	b.WriteString(fmt.Sprintf(" by %s@%s", entry.User, entry.Host))
+	if entry.Mode != "" {
+		b.WriteString(fmt.Sprintf(" (%s)", entry.Mode))
+	}
+	if entry.Via != "" {
+		b.WriteString(fmt.Sprintf(" via %s", entry.Via))
+	}
+	b.WriteString("\n")
+
+	for _, field := range entry.Fields {
+		if entry.Action == ActionAdded || field.Field == "note" {
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", treeIndent, field.Field, auditValue(field.Field, field.New)))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s%s: %s -> %s\n", treeIndent, field.Field,
+			auditValue(field.Field, field.Old), auditValue(field.Field, field.New)))
+	}
+}
+
+// auditTaskID находит ID задачи по ссылке пользователя среди текущих задач, а если задача удалена —
+// по номеру или префиксу ID в журнале аудита.
+func auditTaskID(tasks []models.Task, entries []AuditEntry, ref string) (string, error) {
+	i, err := resolveRef(tasks, ref)
+	if err == nil {
+		return tasks[i].ID, nil
+	}
+	if !errors.Is(err, ErrTaskNotFound) {
+		return "", err
+	}
+
+	// Удаленные задачи ищутся по последнему известному состоянию.
+	var deleted []models.Task
+	seen := make(map[string]bool)
+	for k := len(entries) - 1; k >= 0; k-- {
+		entry := entries[k]
+		if !seen[entry.TaskID] {
+			seen[entry.TaskID] = true
+			deleted = append(deleted, models.Task{ID: entry.TaskID, Index: entry.Index})
+		}
+	}
+
+	i, err = resolveRef(deleted, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return deleted[i].ID, nil
+}
+
+// History возвращает историю изменений задачи по номеру или префиксу ID: кто, когда и в каком режиме
+// ее изменял и какие поля изменились. История доступна и для удаленных задач.
+func History(store Store, tasks []models.Task, ref string) (string, error) {
+	if ref == "" {
+		return "", ErrIndexNotExists
+	}
+
+	entries, err := store.Audit(time.Time{})
+	if err != nil {
+		return "", fmt.Errorf("store.Audit: %w", err)
+	}
+
+	id, err := auditTaskID(tasks, entries, ref)
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var resBuild strings.Builder
+	for _, entry := range entries {
+		if entry.TaskID == id {
+			writeAuditEntry(&resBuild, entry, false)
+		}
+	}
+	if resBuild.Len() == 0 {
+		return "No changes recorded", nil
+	}
+
+	return strings.TrimSuffix(resBuild.String(), "\n"), nil
+}
+
+// parseSince разбирает начало периода журнала. Дата без времени (yesterday, 2025-12-31) означает начало дня.
+func parseSince(element string) (time.Time, error) {
+	if element == "" {
+		return time.Time{}, nil
+	}
+
+	at := timeNow()
+	since, err := dates.Parse(element, at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidSince, element)
+	}
+	if since.Equal(dates.EndOfDay(since)) {
+		since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+	}
+
+	return since, nil
+}
+
+// Log передает в функцию для вывода в терминал журнал изменений всех задач начиная с даты since
+// (без даты — весь журнал).
+func Log(store Store, since string) error {
+	from, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+
+	entries, err := store.Audit(from)
+	if err != nil {
+		return fmt.Errorf("store.Audit: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No changes recorded")
+		return nil
+	}
+
+	var resBuild strings.Builder
+	for _, entry := range entries {
+		writeAuditEntry(&resBuild, entry, true)
+	}
+
+	err = page(resBuild.String())
+	if err != nil {
+		return fmt.Errorf("page: %w", err)
+	}
+	return nil
+}
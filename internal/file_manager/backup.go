@@ -0,0 +1,10 @@
+package filemanager
+
+const (
+	backupSuffix = ".bak"
+)
+
+// backupPath возвращает путь к резервной копии файла задач.
+func backupPath(path string) string {
+	return path + backupSuffix
+}
@@ -0,0 +1,248 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/query"
+)
+
+var (
+	ErrInvalidRange   error = errors.New("an invalid range of task indexes was passed")
+	ErrNotConfirmed   error = errors.New("the operation changes many tasks and was not confirmed")
+	ErrTargetsChanged error = errors.New("the tasks to change were changed in the meantime, run the command again")
+)
+
+// ConfirmThreshold — количество задач из настроек, больше которого массовое изменение выполняется
+// только после подтверждения пользователя.
+var ConfirmThreshold = config.Default().ConfirmThreshold
+
+// SetConfirmThreshold задает порог подтверждения массовых изменений из настроек.
+func SetConfirmThreshold(threshold int) {
+	ConfirmThreshold = threshold
+}
+
+var (
+	// indexList — список номеров и диапазонов номеров задач: 3-7,10.
+	indexList = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+	// singleRef — номер или префикс ID одной задачи. Слова короче MinIDPrefixLength считаются фильтром по названию.
+	singleRef = regexp.MustCompile(fmt.Sprintf(`^(\d+|[0-9a-fA-F-]{%d,})$`, MinIDPrefixLength))
+	// idList — полные ID задач через запятую, которыми обработчики закрепляют подтвержденные задачи (см. PinTargets).
+	idList = regexp.MustCompile(`^[0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12}(,[0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12})*$`)
+)
+
+// indexRange — диапазон номеров задач включительно.
+type indexRange struct {
+	from, to int
+}
+
+// parseIndexList разбирает список номеров и диапазонов номеров задач, например 3-7,10.
+func parseIndexList(target string) ([]indexRange, error) {
+	var ranges []indexRange
+	for _, part := range strings.Split(target, ",") {
+		fromText, toText, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(fromText)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRange, part)
+		}
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(toText)
+			if err != nil || to < from {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRange, part)
+			}
+		}
+		ranges = append(ranges, indexRange{from, to})
+	}
+
+	return ranges, nil
+}
+
+// isSingleTarget сообщает, что цель указывает на одну задачу: номер или префикс ID.
+func isSingleTarget(target string) bool {
+	target = strings.TrimSpace(target)
+	return singleRef.MatchString(target) && !(strings.Contains(target, "-") && indexList.MatchString(target))
+}
+
+// IsTaskList сообщает, что цель указывает на задачи по номерам или ID, а не фильтром:
+// номер, префикс ID или список номеров и диапазонов.
+func IsTaskList(target string) bool {
+	target = strings.TrimSpace(target)
+	return isSingleTarget(target) || indexList.MatchString(target) || idList.MatchString(target)
+}
+
+// PinTargets возвращает цель, которая указывает ровно на задачи targets по их полным ID. Обработчики передают ее
+// в операцию вместо цели пользователя, чтобы изменились именно те задачи, которые пользователь подтвердил,
+// даже если задачи изменились после показа.
+func PinTargets(targets []models.Task) string {
+	ids := make([]string, 0, len(targets))
+	for _, task := range targets {
+		ids = append(ids, task.ID)
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// resolveTargets находит позиции задач, на которые указывает цель пользователя: номер или префикс ID задачи,
+// список номеров и диапазонов (3-7,10) или фильтр (см. пакет query). Номера из диапазонов, которых нет в списке,
+// пропускаются. Для цели из полных ID (см. PinTargets) все задачи должны существовать, иначе возвращается
+// ErrTargetsChanged. Слово из шестнадцатеричных символов, которое не является префиксом ни одного ID (например, cafe),
+// ищется в названиях задач; явно указать ID можно фильтром id:<prefix>.
+// Если ни одна задача не найдена, возвращается ErrTaskNotFound.
+func resolveTargets(tasks []models.Task, target string) ([]int, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, ErrIndexNotExists
+	}
+
+	if idList.MatchString(target) {
+		var positions []int
+		for _, id := range strings.Split(target, ",") {
+			i := slices.IndexFunc(tasks, func(task models.Task) bool { return task.ID == id })
+			if i == -1 {
+				return nil, ErrTargetsChanged
+			}
+			positions = append(positions, i)
+		}
+		return positions, nil
+	}
+
+	if isSingleTarget(target) {
+		i, err := resolveRef(tasks, target)
+		if err == nil {
+			return []int{i}, nil
+		}
+		if _, numErr := strconv.Atoi(target); numErr == nil || !errors.Is(err, ErrTaskNotFound) {
+			return nil, err
+		}
+	}
+
+	var match func(task models.Task) bool
+	if indexList.MatchString(target) {
+		ranges, err := parseIndexList(target)
+		if err != nil {
+			return nil, err
+		}
+		match = func(task models.Task) bool {
+			for _, r := range ranges {
+				if task.Index >= r.from && task.Index <= r.to {
+					return true
+				}
+			}
+			return false
+		}
+	} else {
+		q, err := query.Parse(target, queryEnv())
+		if err != nil {
+			return nil, err
+		}
+		match = q.Match
+	}
+
+	var positions []int
+	for i, task := range tasks {
+		if match(task) {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	return positions, nil
+}
+
+// Targets возвращает задачи, на которые указывает цель пользователя (см. resolveTargets), чтобы перед
+// массовым изменением показать их пользователю и запросить подтверждение.
+func Targets(tasks []models.Task, target string) ([]models.Task, error) {
+	positions, err := resolveTargets(tasks, target)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]models.Task, 0, len(positions))
+	for _, i := range positions {
+		targets = append(targets, tasks[i])
+	}
+
+	return targets, nil
+}
+
+// TargetsSummary возвращает список затронутых массовым изменением задач и вопрос о его подтверждении.
+func TargetsSummary(targets []models.Task, action string) (list, question string) {
+	var resBuild strings.Builder
+	for _, task := range targets {
+		resBuild.WriteString(fmt.Sprintf("%s%d %s\n", treeIndent, task.Index, task.Name))
+	}
+
+	return resBuild.String(), fmt.Sprintf("%s %d tasks? [y/N]: ", action, len(targets))
+}
+
+// notFoundMessage возвращает сообщение для цели, под которую не подошла ни одна задача.
+func notFoundMessage(target string) string {
+	if isSingleTarget(target) {
+		return "Task not found"
+	}
+
+	return "No tasks found"
+}
+
+// modifyTasks применяет fn ко всем задачам, на которые указывает цель пользователя, за одно изменение хранилища.
+// Переходы статусов проверяются для каждой задачи; если хотя бы один переход не разрешен, хранилище не изменяется.
+// Возвращает измененные задачи и следующие вхождения завершенных повторяющихся задач.
+func modifyTasks(store Store, target string, fn func(task *models.Task)) (modified, next []models.Task, err error) {
+	err = store.Modify(func(list *TaskList) error {
+		positions, err := resolveTargets(list.Tasks, target)
+		if err != nil {
+			return err
+		}
+
+		at := timeNow()
+		modified, next = nil, nil
+		for _, i := range positions {
+			before := list.Tasks[i]
+			fn(&list.Tasks[i])
+
+			err = checkTransition(before.Status, list.Tasks[i].Status)
+			if err != nil && len(positions) > 1 {
+				return fmt.Errorf("task %d: %w", before.Index, err)
+			}
+			if err != nil {
+				return err
+			}
+			if !isDone(before) {
+				if occurrence, ok := recur(list, i, at); ok {
+					next = append(next, occurrence)
+				}
+			}
+			modified = append(modified, list.Tasks[i])
+		}
+		return nil
+	})
+
+	return modified, next, err
+}
+
+// updatedTasksMessage возвращает сообщение об изменении задач: для одной задачи — как updatedMessage,
+// для нескольких — количество измененных задач и созданных следующих вхождений.
+func updatedTasksMessage(modified, next []models.Task) string {
+	if len(modified) == 1 {
+		if len(next) == 0 {
+			return updatedMessage(models.Task{})
+		}
+		return updatedMessage(next[0])
+	}
+
+	message := fmt.Sprintf("%d tasks updated", len(modified))
+	if len(next) > 0 {
+		message += fmt.Sprintf(". Next occurrences added: %d", len(next))
+	}
+
+	return message
+}
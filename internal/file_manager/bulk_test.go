@@ -0,0 +1,81 @@
+package filemanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestResolveTargets(t *testing.T) {
+	tasks := []models.Task{
+		{ID: "aaaa1111-0000-4000-8000-000000000001", Index: 1, Name: "Deploy backend", Status: "todo", Tags: []string{"urgent"}},
+		{ID: "aaaa2222-0000-4000-8000-000000000002", Index: 2, Name: "Deploy frontend", Status: "done"},
+		{ID: "bbbb3333-0000-4000-8000-000000000003", Index: 4, Name: "Buy coffee at the cafe", Status: "todo"},
+		{ID: "cccc4444-0000-4000-8000-000000000004", Index: 7, Name: "Write docs", Status: "in-progress"},
+	}
+
+	tests := []struct {
+		target  string
+		want    []int
+		wantErr error
+	}{
+		{target: "2", want: []int{1}},
+		{target: " 7 ", want: []int{3}},
+		{target: "1-4", want: []int{0, 1, 2}},
+		{target: "7,1-2", want: []int{0, 1, 3}},
+		{target: "3-6", want: []int{2}},
+		{target: "bbbb", want: []int{2}},
+		{target: "aaaa2222-0000", want: []int{1}},
+		{target: "cafe", want: []int{2}},
+		{target: "deploy", want: []int{0, 1}},
+		{target: "+urgent", want: []int{0}},
+		{target: "status:todo", want: []int{0, 2}},
+		{target: "id:cccc", want: []int{3}},
+		{target: tasks[3].ID + "," + tasks[0].ID, want: []int{3, 0}},
+		{target: "", wantErr: ErrIndexNotExists},
+		{target: "3", wantErr: ErrTaskNotFound},
+		{target: "8-9", wantErr: ErrTaskNotFound},
+		{target: "7-3", wantErr: ErrInvalidRange},
+		{target: "aaaa", wantErr: ErrAmbiguousID},
+		{target: "nothing", wantErr: ErrTaskNotFound},
+		{target: tasks[0].ID + ",dddd5555-0000-4000-8000-000000000005", wantErr: ErrTargetsChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := resolveTargets(tasks, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveTargets(%q) error = %v, want %v", tt.target, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("resolveTargets(%q) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinTargets(t *testing.T) {
+	tasks := []models.Task{
+		{ID: "aaaa1111-0000-4000-8000-000000000001", Index: 1, Name: "a"},
+		{ID: "bbbb2222-0000-4000-8000-000000000002", Index: 2, Name: "b"},
+		{ID: "cccc3333-0000-4000-8000-000000000003", Index: 3, Name: "c"},
+	}
+
+	targets, err := Targets(tasks, "1-2")
+	if err != nil {
+		t.Fatalf("Targets: %v", err)
+	}
+	pinned := PinTargets(targets)
+	if !IsTaskList(pinned) {
+		t.Errorf("IsTaskList(%q) = false, want true", pinned)
+	}
+
+	// Задачу 2 удалили после подтверждения, а на ее место встала новая задача под номером 2.
+	changed := []models.Task{tasks[0], {ID: "dddd4444-0000-4000-8000-000000000004", Index: 2, Name: "new"}, tasks[2]}
+	_, err = resolveTargets(changed, pinned)
+	if !errors.Is(err, ErrTargetsChanged) {
+		t.Errorf("resolveTargets after a change: error = %v, want %v", err, ErrTargetsChanged)
+	}
+}
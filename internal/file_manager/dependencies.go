@@ -0,0 +1,217 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var (
+	ErrDependencyNotExists error = errors.New("dependency is missing from the passed arguments")
+	errDependencyCycle     error = errors.New("the dependency would create a cycle")
+	errDependencyExists    error = errors.New("the dependency already exists")
+	errDependencyNotFound  error = errors.New("dependency task not found")
+	errDependencyIsSelf    error = errors.New("a task cannot depend on itself")
+)
+
+// blockers возвращает незавершенные задачи, от которых зависит задача.
+func blockers(tasks []models.Task, task models.Task) []models.Task {
+	var result []models.Task
+	for _, id := range task.DependsOn {
+		i := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == id })
+		if i != -1 && !isDone(tasks[i]) {
+			result = append(result, tasks[i])
+		}
+	}
+
+	return result
+}
+
+// isBlocked сообщает, что задача не может быть начата, пока не выполнены задачи, от которых она зависит.
+func isBlocked(tasks []models.Task, task models.Task) bool {
+	return !isDone(task) && len(blockers(tasks, task)) > 0
+}
+
+// blockerIndexes возвращает номера блокирующих задач через запятую для вывода в терминал.
+func blockerIndexes(tasks []models.Task) string {
+	indexes := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		indexes = append(indexes, strconv.Itoa(task.Index))
+	}
+
+	return strings.Join(indexes, ",")
+}
+
+// dependsOn сообщает, зависит ли задача fromID от задачи toID напрямую или через другие задачи.
+func dependsOn(tasks []models.Task, fromID, toID string) bool {
+	visited := make(map[string]bool)
+	stack := []string{fromID}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == toID {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		i := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == id })
+		if i != -1 {
+			stack = append(stack, tasks[i].DependsOn...)
+		}
+	}
+
+	return false
+}
+
+// removeDependencies удаляет ссылки на удаленные задачи из зависимостей оставшихся задач.
+func removeDependencies(tasks []models.Task, removed map[string]bool) {
+	for i := range tasks {
+		if len(tasks[i].DependsOn) == 0 {
+			continue
+		}
+
+		// Зависимости копируются: исходный слайс может быть общим со снимком задач до изменения.
+		tasks[i].DependsOn = slices.DeleteFunc(slices.Clone(tasks[i].DependsOn), func(id string) bool { return removed[id] })
+		if len(tasks[i].DependsOn) == 0 {
+			tasks[i].DependsOn = nil
+		}
+	}
+}
+
+// Link реализует добавление зависимости: задача из первого аргумента не может быть начата,
+// пока не выполнена задача из второго аргумента. Зависимость, создающая цикл, не добавляется;
+// если зависимость уже есть, хранилище не изменяется. После чего возвращает сообщение о результате действия или ошибку.
+func Link(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+	if elements[1] == "" {
+		return "", ErrDependencyNotExists
+	}
+
+	err := store.Modify(func(list *TaskList) error {
+		i, err := resolveRef(list.Tasks, elements[0])
+		if err != nil {
+			return err
+		}
+
+		d, err := resolveRef(list.Tasks, elements[1])
+		if errors.Is(err, ErrTaskNotFound) {
+			return fmt.Errorf("%w: %s", errDependencyNotFound, elements[1])
+		}
+		if err != nil {
+			return err
+		}
+
+		task, dependency := &list.Tasks[i], list.Tasks[d]
+		switch {
+		case task.ID == dependency.ID:
+			return errDependencyIsSelf
+		case slices.Contains(task.DependsOn, dependency.ID):
+			return errDependencyExists
+		case dependsOn(list.Tasks, dependency.ID, task.ID):
+			return fmt.Errorf("%w: task %d already depends on task %d", errDependencyCycle, dependency.Index, task.Index)
+		}
+
+		task.DependsOn = append(task.DependsOn, dependency.ID)
+		task.UpdatedAt = timeNow()
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if errors.Is(err, errDependencyExists) {
+		return "Dependency already exists", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return "Dependency added", nil
+}
+
+// Unlink реализует удаление зависимости задачи из первого аргумента от задачи из второго аргумента.
+// После чего возвращает сообщение о результате действия или ошибку.
+func Unlink(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+	if elements[1] == "" {
+		return "", ErrDependencyNotExists
+	}
+
+	removed := false
+	err := store.Modify(func(list *TaskList) error {
+		i, err := resolveRef(list.Tasks, elements[0])
+		if err != nil {
+			return err
+		}
+
+		d, err := resolveRef(list.Tasks, elements[1])
+		if errors.Is(err, ErrTaskNotFound) {
+			return fmt.Errorf("%w: %s", errDependencyNotFound, elements[1])
+		}
+		if err != nil {
+			return err
+		}
+
+		task := &list.Tasks[i]
+		before := len(task.DependsOn)
+		task.DependsOn = slices.DeleteFunc(slices.Clone(task.DependsOn), func(id string) bool { return id == list.Tasks[d].ID })
+		if len(task.DependsOn) == 0 {
+			task.DependsOn = nil
+		}
+
+		removed = len(task.DependsOn) != before
+		if removed {
+			task.UpdatedAt = timeNow()
+		}
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	if !removed {
+		return "Dependency not found", nil
+	}
+
+	return "Dependency removed", nil
+}
+
+// ReadyTasks передает в функцию для вывода в терминал список не начатых задач, которые ничем не заблокированы.
+func ReadyTasks(tasks []models.Task, opts ListOptions) error {
+	var ready []models.Task
+	for _, task := range tasks {
+		if task.Status == initialStatus() && !isBlocked(tasks, task) {
+			ready = append(ready, task)
+		}
+	}
+
+	err := printTasks(tasks, ready, opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package filemanager
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestLink(t *testing.T) {
+	type step struct {
+		op      func(store Store, tasks *[]models.Task, elements []string) (string, error)
+		task    string
+		dep     string
+		want    string
+		wantErr error
+	}
+
+	tests := []struct {
+		name     string
+		steps    []step
+		wantDeps map[int][]int
+	}{
+		{
+			name:     "add",
+			steps:    []step{{op: Link, task: "1", dep: "2", want: "Dependency added"}},
+			wantDeps: map[int][]int{1: {2}},
+		},
+		{
+			name: "already exists",
+			steps: []step{
+				{op: Link, task: "1", dep: "2", want: "Dependency added"},
+				{op: Link, task: "1", dep: "2", want: "Dependency already exists"},
+			},
+			wantDeps: map[int][]int{1: {2}},
+		},
+		{
+			name:     "self",
+			steps:    []step{{op: Link, task: "1", dep: "1", wantErr: errDependencyIsSelf}},
+			wantDeps: map[int][]int{},
+		},
+		{
+			name: "cycle",
+			steps: []step{
+				{op: Link, task: "1", dep: "2", want: "Dependency added"},
+				{op: Link, task: "2", dep: "3", want: "Dependency added"},
+				{op: Link, task: "3", dep: "1", wantErr: errDependencyCycle},
+			},
+			wantDeps: map[int][]int{1: {2}, 2: {3}},
+		},
+		{
+			name:     "unknown dependency",
+			steps:    []step{{op: Link, task: "1", dep: "9", wantErr: errDependencyNotFound}},
+			wantDeps: map[int][]int{},
+		},
+		{
+			name:     "unknown task",
+			steps:    []step{{op: Link, task: "9", dep: "1", want: "Task not found"}},
+			wantDeps: map[int][]int{},
+		},
+		{
+			name: "unlink",
+			steps: []step{
+				{op: Link, task: "1", dep: "2", want: "Dependency added"},
+				{op: Link, task: "1", dep: "3", want: "Dependency added"},
+				{op: Unlink, task: "1", dep: "2", want: "Dependency removed"},
+				{op: Unlink, task: "1", dep: "2", want: "Dependency not found"},
+			},
+			wantDeps: map[int][]int{1: {3}},
+		},
+	}
+
+	for storeName, newStore := range testStores() {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				store := newStore(t)
+				addTasks(t, store, "a", "b", "c")
+
+				var tasks []models.Task
+				for i, s := range tt.steps {
+					got, err := s.op(store, &tasks, []string{s.task, s.dep})
+					if !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d: error = %v, want %v", i, err, s.wantErr)
+					}
+					if got != s.want {
+						t.Fatalf("step %d: message = %q, want %q", i, got, s.want)
+					}
+				}
+
+				if got := dependencyIndexes(t, store); !maps.EqualFunc(got, tt.wantDeps, slices.Equal) {
+					t.Errorf("dependencies = %v, want %v", got, tt.wantDeps)
+				}
+			})
+		}
+	}
+}
+
+// TestLinkExistingIsNotRecorded проверяет, что повторное добавление зависимости не попадает в журнал:
+// Undo отменяет исходное добавление.
+func TestLinkExistingIsNotRecorded(t *testing.T) {
+	for storeName, newStore := range testStores() {
+		t.Run(storeName, func(t *testing.T) {
+			store := newStore(t)
+			addTasks(t, store, "a", "b")
+
+			var tasks []models.Task
+			for range 2 {
+				_, err := Link(store, &tasks, []string{"1", "2"})
+				if err != nil {
+					t.Fatalf("Link: %v", err)
+				}
+			}
+
+			_, err := store.Undo()
+			if err != nil {
+				t.Fatalf("store.Undo: %v", err)
+			}
+			if got := dependencyIndexes(t, store); len(got) != 0 {
+				t.Errorf("dependencies after Undo = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestEmptyTrashRemovesDependencies(t *testing.T) {
+	for storeName, newStore := range testStores() {
+		t.Run(storeName, func(t *testing.T) {
+			store := newStore(t)
+			addTasks(t, store, "a", "b", "c")
+
+			var tasks []models.Task
+			for _, dep := range []string{"2", "3"} {
+				_, err := Link(store, &tasks, []string{"1", dep})
+				if err != nil {
+					t.Fatalf("Link: %v", err)
+				}
+			}
+
+			_, err := Delete(store, &tasks, []string{"2"})
+			if err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			_, err = EmptyTrash(store, &tasks, nil)
+			if err != nil {
+				t.Fatalf("EmptyTrash: %v", err)
+			}
+
+			want := map[int][]int{1: {3}}
+			if got := dependencyIndexes(t, store); !maps.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("dependencies = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// dependencyIndexes возвращает номера задач, от которых зависят задачи хранилища, по номерам задач.
+func dependencyIndexes(t *testing.T, store Store) map[int][]int {
+	t.Helper()
+
+	tasks, err := store.List()
+	if err != nil {
+		t.Fatalf("store.List: %v", err)
+	}
+
+	deps := make(map[int][]int)
+	for _, task := range tasks {
+		for _, id := range task.DependsOn {
+			i := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == id })
+			if i == -1 {
+				t.Fatalf("task %d depends on a missing task %s", task.Index, id)
+			}
+			deps[task.Index] = append(deps[task.Index], tasks[i].Index)
+		}
+	}
+
+	return deps
+}
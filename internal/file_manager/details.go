@@ -0,0 +1,165 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var ErrNoteNotExists error = errors.New("note text is missing from the passed arguments")
+
+// detailTime выводит момент времени вместе с относительным временем или "-", если время не задано.
+func detailTime(t, at time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), relativeTime(t, at))
+}
+
+// taskRefs выводит номера и названия задач через запятую.
+func taskRefs(tasks []models.Task) string {
+	refs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ref := fmt.Sprintf("%d %s", task.Index, task.Name)
+		if isDone(task) {
+			ref += " (done)"
+		}
+		refs = append(refs, ref)
+	}
+
+	return strings.Join(refs, ", ")
+}
+
+// indentLines добавляет отступ к каждой строке многострочного текста.
+func indentLines(text, indent string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Show возвращает подробное описание задачи по номеру или префиксу ID: все поля, связи с другими задачами,
+// описание и заметки.
+func Show(tasks []models.Task, ref string) (string, error) {
+	if ref == "" {
+		return "", ErrIndexNotExists
+	}
+
+	i, err := resolveRef(tasks, ref)
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	task := tasks[i]
+	at := timeNow()
+	var resBuild strings.Builder
+	field := func(name, value string) {
+		resBuild.WriteString(fmt.Sprintf("%-12s %s\n", name+":", value))
+	}
+
+	resBuild.WriteString(fmt.Sprintf("Task %d (ID %s)\n", task.Index, task.ID))
+	field("Name", task.Name)
+	field("Status", statusName(task.Status))
+	field("Priority", fmt.Sprintf("%s (urgency %.2f)", priorityName(task.Priority), Urgency(task, at)))
+	if task.Project != "" {
+		field("Project", task.Project)
+	}
+	if len(task.Tags) > 0 {
+		field("Tags", strings.Join(task.Tags, ", "))
+	}
+	if !task.Due.IsZero() {
+		due := fmt.Sprintf("%s (%s)", dates.Format(task.Due), relativeTime(task.Due, at))
+		if isOverdue(task, at) {
+			due += " OVERDUE"
+		}
+		field("Due", due)
+	}
+	if task.Recur != "" {
+		field("Recur", fmt.Sprintf("%s (%d done)", task.Recur, completedOccurrences(tasks, task)))
+	}
+	if task.ParentID != "" {
+		if p := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == task.ParentID }); p != -1 {
+			field("Parent", taskRefs(tasks[p:p+1]))
+		}
+	}
+	if subtasks := children(tasks, task.ID); len(subtasks) > 0 {
+		done, total := progress(tasks, task)
+		field("Subtasks", fmt.Sprintf("%d/%d done: %s", done, total, taskRefs(subtasks)))
+	}
+	if len(task.DependsOn) > 0 {
+		var dependencies []models.Task
+		for _, id := range task.DependsOn {
+			if d := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == id }); d != -1 {
+				dependencies = append(dependencies, tasks[d])
+			}
+		}
+		field("Depends on", taskRefs(dependencies))
+		if isBlocked(tasks, task) {
+			field("Blocked by", blockerIndexes(blockers(tasks, task)))
+		}
+	}
+	field("Created", detailTime(task.CreatedAt, at))
+	field("Updated", detailTime(task.UpdatedAt, at))
+	field("Started", detailTime(task.StartedAt, at))
+	field("Completed", detailTime(task.CompletedAt, at))
+
+	if task.Description != "" {
+		resBuild.WriteString("\nDescription:\n")
+		resBuild.WriteString(indentLines(task.Description, "  "))
+		resBuild.WriteString("\n")
+	}
+
+	if len(task.Notes) > 0 {
+		resBuild.WriteString("\nNotes:\n")
+		for _, note := range task.Notes {
+			resBuild.WriteString(fmt.Sprintf("  %s (%s)\n", note.At.Local().Format("2006-01-02 15:04"), relativeTime(note.At, at)))
+			resBuild.WriteString(indentLines(note.Text, "    "))
+			resBuild.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(resBuild.String(), "\n"), nil
+}
+
+// AddNote реализует добавление заметки с текущим временем к задаче по номеру или префиксу ID задачи.
+// Ранее добавленные заметки не изменяются. После чего возвращает сообщение о результате действия или ошибку.
+func AddNote(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+
+	text := strings.TrimSpace(trimQuotes(elements[1]))
+	if text == "" {
+		return "", ErrNoteNotExists
+	}
+
+	_, err := modifyTask(store, elements[0], func(task *models.Task) {
+		at := timeNow()
+		task.Notes = append(task.Notes, models.Note{At: at, Text: text})
+		task.UpdatedAt = at
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("modifyTask: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return "Note added", nil
+}
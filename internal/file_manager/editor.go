@@ -0,0 +1,286 @@
+package filemanager
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	// frontMatterDelimiter отделяет поля задачи от описания во временном файле редактора.
+	frontMatterDelimiter = "---"
+)
+
+var (
+	errIncorrectFrontMatter error = errors.New("an incorrect task file was saved")
+	errEditConflict         error = errors.New("the task was changed by another command while it was being edited")
+)
+
+// taskEdit — поля задачи, которые пользователь может изменить в редакторе.
+type taskEdit struct {
+	name     string
+	status   models.TaskStatus
+	priority models.Priority
+	due      time.Time
+	// dueText — срок в том виде, в котором он записан в файле.
+	dueText     string
+	tags        []string
+	project     string
+	recur       string
+	description string
+}
+
+// editorCommand возвращает команду редактора из переменных окружения VISUAL или EDITOR.
+// Если они не заданы, используется vi (notepad в Windows).
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// formatTaskFile записывает поля задачи в формате front matter: поля между строками "---",
+// после них — описание задачи.
+func formatTaskFile(task models.Task) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, frontMatterDelimiter)
+	fmt.Fprintf(&buf, "name: %s\n", task.Name)
+	fmt.Fprintf(&buf, "status: %s\n", task.Status)
+	fmt.Fprintf(&buf, "priority: %s\n", strings.ToLower(priorityName(task.Priority)))
+	fmt.Fprintf(&buf, "due: %s\n", formatDue(task.Due))
+	fmt.Fprintf(&buf, "tags: %s\n", strings.Join(task.Tags, ", "))
+	fmt.Fprintf(&buf, "project: %s\n", task.Project)
+	fmt.Fprintf(&buf, "recur: %s\n", task.Recur)
+	fmt.Fprintln(&buf, frontMatterDelimiter)
+	if task.Description != "" {
+		fmt.Fprintln(&buf, task.Description)
+	}
+
+	return buf.Bytes()
+}
+
+// formatDue возвращает срок задачи в том виде, в котором он записывается в файл, или пустую строку, если срока нет.
+func formatDue(due time.Time) string {
+	if due.IsZero() {
+		return ""
+	}
+
+	return dates.Format(due)
+}
+
+// parseTaskFile разбирает файл, сохраненный пользователем в редакторе. Пустые значения полей,
+// кроме названия и статуса, удаляют соответствующее поле задачи. В ошибках указывается номер строки.
+func parseTaskFile(data []byte) (taskEdit, error) {
+	var edit taskEdit
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == frontMatterDelimiter {
+			break
+		}
+		if strings.TrimSpace(scanner.Text()) != "" {
+			return taskEdit{}, fmt.Errorf("%w: line %d: expected %q", errIncorrectFrontMatter, line, frontMatterDelimiter)
+		}
+	}
+
+	seen := make(map[string]bool)
+	closed := false
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == frontMatterDelimiter {
+			closed = true
+			break
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return taskEdit{}, fmt.Errorf("%w: line %d: expected \"field: value\"", errIncorrectFrontMatter, line)
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "name":
+			edit.name = trimQuotes(value)
+		case "status":
+			edit.status, err = parseStatus(value)
+		case "priority":
+			if value != "" {
+				edit.priority, err = parsePriority(value)
+			}
+		case "due":
+			edit.dueText = value
+			if value != "" {
+				edit.due, err = parseDue(value)
+			}
+		case "tags":
+			edit.tags, err = applyTagChanges(nil, value)
+		case "project":
+			edit.project = normalizeProject(value)
+		case "recur":
+			if value != "" {
+				edit.recur, err = parseRecurrence(value)
+			}
+		default:
+			err = fmt.Errorf("unknown field %q", key)
+		}
+		if err != nil {
+			return taskEdit{}, fmt.Errorf("%w: line %d: %w", errIncorrectFrontMatter, line, err)
+		}
+	}
+	if !closed {
+		return taskEdit{}, fmt.Errorf("%w: closing %q not found", errIncorrectFrontMatter, frontMatterDelimiter)
+	}
+	if edit.name == "" || !seen["status"] {
+		return taskEdit{}, fmt.Errorf("%w: name and status are required", errIncorrectFrontMatter)
+	}
+
+	var description strings.Builder
+	for scanner.Scan() {
+		description.WriteString(scanner.Text())
+		description.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return taskEdit{}, fmt.Errorf("scanner.Err: %w", err)
+	}
+	edit.description = strings.TrimSpace(description.String())
+
+	return edit, nil
+}
+
+// runEditor открывает файл в редакторе пользователя и ждет его закрытия.
+func runEditor(path string) error {
+	command := editorCommand()
+	cmd := exec.Command(command[0], append(command[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// Edit реализует редактирование задачи по номеру или префиксу ID в редакторе из $EDITOR.
+// Название, статус, приоритет, срок, теги, проект, правило повторения и описание задачи записываются
+// во временный файл, а после закрытия редактора разбираются и сохраняются. Если файл не удалось разобрать
+// или задачу за время редактирования изменила другая команда, изменения не сохраняются, а файл не удаляется,
+// чтобы изменения пользователя не потерялись.
+// После чего возвращает сообщение о результате действия или ошибку.
+func Edit(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+
+	err := refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	i, err := resolveRef(*tasks, elements[0])
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	task := (*tasks)[i]
+
+	file, err := os.CreateTemp("", fmt.Sprintf("task-%d-*.md", task.Index))
+	if err != nil {
+		return "", fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	path := file.Name()
+
+	original := formatTaskFile(task)
+	_, err = file.Write(original)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("write temporary file: %w", err)
+	}
+
+	err = runEditor(path)
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("runEditor: %w", err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("os.ReadFile: %w", err)
+	}
+	if bytes.Equal(edited, original) {
+		os.Remove(path)
+		return "No changes", nil
+	}
+
+	edit, err := parseTaskFile(edited)
+	if err != nil {
+		return "", fmt.Errorf("%w (the edited file is kept in %s)", err, path)
+	}
+
+	// Редактор работает без блокировки хранилища, поэтому перед сохранением задача сравнивается
+	// с состоянием, записанным в файл: изменения другой команды не перезаписываются.
+	conflict := false
+	next, err := modifyTask(store, task.ID, func(current *models.Task) {
+		if !sameTask(*current, task) {
+			conflict = true
+			return
+		}
+
+		at := timeNow()
+		setStatus(current, edit.status, at)
+		current.Name = edit.name
+		current.Priority = edit.priority
+		// Срок записывается в файл с точностью до минуты, поэтому неизмененный срок не разбирается заново,
+		// иначе каждое редактирование сбрасывало бы секунды срока.
+		if edit.dueText != formatDue(current.Due) {
+			current.Due = edit.due
+		}
+		current.Tags = edit.tags
+		current.Project = edit.project
+		current.Recur = edit.recur
+		current.Description = edit.description
+		current.UpdatedAt = at
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "", fmt.Errorf("%w (the edited file is kept in %s)", ErrTaskNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("modifyTask: %w (the edited file is kept in %s)", err, path)
+	}
+	if conflict {
+		return "", fmt.Errorf("%w, run the command again (the edited file is kept in %s)", errEditConflict, path)
+	}
+	os.Remove(path)
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return updatedMessage(next), nil
+}
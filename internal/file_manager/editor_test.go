@@ -0,0 +1,136 @@
+package filemanager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestParseTaskFile(t *testing.T) {
+	data := "\n---\n" +
+		"name: \"Buy milk\"\n" +
+		"status: d\n" +
+		"priority: high\n" +
+		"# comment\n" +
+		"\n" +
+		"due: 2026-02-01\n" +
+		"tags: home, urgent\n" +
+		"project: home\n" +
+		"recur: daily\n" +
+		"---\n" +
+		"First line\n\nSecond line\n"
+
+	edit, err := parseTaskFile([]byte(data))
+	if err != nil {
+		t.Fatalf("parseTaskFile: %v", err)
+	}
+	if edit.name != "Buy milk" || edit.status != "done" || edit.priority != models.PriorityHigh {
+		t.Errorf("name, status, priority = %q, %q, %v", edit.name, edit.status, edit.priority)
+	}
+	if edit.dueText != "2026-02-01" || edit.due.IsZero() {
+		t.Errorf("due = %v (%q), want 2026-02-01", edit.due, edit.dueText)
+	}
+	if !slices.Equal(edit.tags, []string{"home", "urgent"}) || edit.project != "home" || edit.recur != "daily" {
+		t.Errorf("tags, project, recur = %v, %q, %q", edit.tags, edit.project, edit.recur)
+	}
+	if edit.description != "First line\n\nSecond line" {
+		t.Errorf("description = %q", edit.description)
+	}
+}
+
+func TestParseTaskFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"no opening delimiter", "name: a\n---\n", `line 1: expected "---"`},
+		{"no colon", "---\nname a\n---\n", `line 2: expected "field: value"`},
+		{"unknown status", "---\nname: a\nstatus: blocked\n---\n", "line 3: an incorrect task status"},
+		{"wrong priority", "---\nname: a\nstatus: todo\n\npriority: huge\n---\n", "line 5:"},
+		{"wrong due date", "---\nname: a\nstatus: todo\ndue: someday\n---\n", "line 4:"},
+		{"unknown field", "---\nname: a\nstatus: todo\ncolor: red\n---\n", `line 4: unknown field "color"`},
+		{"no closing delimiter", "---\nname: a\nstatus: todo\n", `closing "---" not found`},
+		{"no status", "---\nname: a\n---\n", "name and status are required"},
+		{"empty name", "---\nname:\nstatus: todo\n---\n", "name and status are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTaskFile([]byte(tt.data))
+			if !errors.Is(err, errIncorrectFrontMatter) {
+				t.Fatalf("parseTaskFile error = %v, want %v", err, errIncorrectFrontMatter)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseTaskFile error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestEditConflict запускает вместо редактора скрипт, который переименовывает задачу во временном файле,
+// а в случае конфликта еще и подменяет файл задач, как если бы задачу за это время изменила другая команда.
+func TestEditConflict(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test editor is a shell script")
+	}
+
+	tests := []struct {
+		name      string
+		conflict  bool
+		wantErr   error
+		wantNames []string
+	}{
+		{name: "no conflict", wantNames: []string{"edited"}},
+		{name: "task changed", conflict: true, wantErr: errEditConflict, wantNames: []string{"changed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "tasks.json")
+			store, err := NewJSONStore(Location{Path: path})
+			if err != nil {
+				t.Fatalf("NewJSONStore: %v", err)
+			}
+			addTasks(t, store, "a")
+
+			script := "#!/bin/sh\nsed 's/^name: a$/name: edited/' \"$1\" > \"$1.tmp\" && mv \"$1.tmp\" \"$1\"\n"
+			if tt.conflict {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("os.ReadFile: %v", err)
+				}
+				changed := filepath.Join(dir, "changed.json")
+				err = os.WriteFile(changed, bytes.Replace(data, []byte(`"name": "a"`), []byte(`"name": "changed"`), 1), 0644)
+				if err != nil {
+					t.Fatalf("os.WriteFile: %v", err)
+				}
+				script += fmt.Sprintf("cp %q %q\n", changed, path)
+			}
+			editor := filepath.Join(dir, "editor.sh")
+			err = os.WriteFile(editor, []byte(script), 0755)
+			if err != nil {
+				t.Fatalf("os.WriteFile: %v", err)
+			}
+			t.Setenv("VISUAL", editor)
+
+			var tasks []models.Task
+			_, err = Edit(store, &tasks, []string{"1"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Edit error = %v, want %v", err, tt.wantErr)
+			}
+			if got := taskNames(t, store); !slices.Equal(got, tt.wantNames) {
+				t.Errorf("tasks = %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
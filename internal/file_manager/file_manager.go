@@ -1,321 +1,468 @@
 package filemanager
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
 	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
 )
 
-const (
-	tasksPath = "tasks.json"
-)
-
 var (
 	ErrInputElementsCount error = errors.New("incorrect number of arguments passed")
-	errAtoi               error = errors.New("an invalid number was passed")
 	errIncorrectStatus    error = errors.New("an incorrect task status was passed")
 	ErrNameNotExists      error = errors.New("name is missing from the passed arguments")
 	ErrIndexNotExists     error = errors.New("index is missing from the passed arguments")
 	ErrStatusNotExists    error = errors.New("status is missing from the passed arguments")
+	ErrDueNotExists       error = errors.New("due date is missing from the passed arguments")
+	ErrPriorityNotExists  error = errors.New("priority is missing from the passed arguments")
 	ErrInvalidCommand     error = errors.New("an invalid command was entered")
 )
 
-// CreateFile проверяет наличие файла в текущей директории.
-// Если его нет, то он будет создан.
-func CreateFile() error {
-	_, err := os.Stat(tasksPath)
-	if err == nil {
-		//fmt.Printf("Файл '%s' существует\n", tasksPath)
-		return nil
+// refresh перечитывает задачи из хранилища в переданный слайс, чтобы после изменения
+// обработчик работал с актуальным списком.
+func refresh(store Store, tasks *[]models.Task) error {
+	actual, err := store.List()
+	if err != nil {
+		return fmt.Errorf("store.List: %w", err)
+	}
+
+	*tasks = actual
+	return nil
+}
+
+// Add реализует добавление новой задачи в хранилище. Следующими аргументами могут быть переданы
+// срок выполнения, приоритет, теги через запятую, проект, номер или префикс ID родительской задачи,
+// правило повторения и описание.
+// Номер новой задачи берется из счетчика хранилища, поэтому номера удаленных задач не используются повторно. После чего возвращает сообщение о результате действия или ошибку.
+func Add(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	newName := strings.ReplaceAll(strings.ReplaceAll(elements[0], "\"", ""), "'", "")
+	if newName == "" {
+		return "", ErrNameNotExists
+	}
 
-	} else if os.IsNotExist(err) {
-		file, err := os.Create(tasksPath)
+	tags, err := applyTagChanges(nil, optionalElement(elements, 3))
+	if err != nil {
+		return "", err
+	}
+
+	var due time.Time
+	if len(elements) > 1 && elements[1] != "" {
+		due, err = parseDue(elements[1])
 		if err != nil {
-			return fmt.Errorf("create file: %w", err)
+			return "", err
 		}
+	}
 
-		err = file.Close()
+	var priority models.Priority
+	if len(elements) > 2 && elements[2] != "" {
+		priority, err = parsePriority(elements[2])
 		if err != nil {
-			return fmt.Errorf("close file: %w", err)
+			return "", err
 		}
+	}
 
-		fmt.Printf("File '%s' created\n", tasksPath)
-
-	} else {
-		return fmt.Errorf("file check: %w", err)
+	var recurRule string
+	if rule := optionalElement(elements, 6); rule != "" {
+		recurRule, err = parseRecurrence(rule)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	return nil
-}
+	var added models.Task
+	err = store.Modify(func(list *TaskList) error {
+		var parentID string
+		if parentRef := optionalElement(elements, 5); parentRef != "" {
+			id, err := resolveParent(list.Tasks, parentRef)
+			if err != nil {
+				return err
+			}
+			parentID = id
+		}
 
-// GetAllTasks реализует считывание всех задач из файла, преобразует их из JSON в объекты типа Task и возвращает их.
-func GetAllTasks() ([]models.Task, error) {
-	data, err := os.ReadFile(tasksPath)
-	if err != nil {
-		return nil, fmt.Errorf("os.ReadFile: %w", err)
-	}
+		at := timeNow()
+		added = list.NewTask(models.Task{
+			Name:        newName,
+			Status:      initialStatus(),
+			CreatedAt:   at,
+			UpdatedAt:   at,
+			Due:         due,
+			Priority:    priority,
+			Tags:        tags,
+			Project:     normalizeProject(optionalElement(elements, 4)),
+			ParentID:    parentID,
+			Recur:       recurRule,
+			Description: strings.TrimSpace(optionalElement(elements, 7)),
+		})
 
-	allTasks := make([]models.Task, 0)
-	if len(data) == 0 {
-		return allTasks, nil
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
 	}
 
-	err = json.Unmarshal(data, &allTasks)
+	err = refresh(store, tasks)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		return "", fmt.Errorf("refresh: %w", err)
 	}
 
-	return allTasks, nil
+	return fmt.Sprintf("Task %d added (ID %s)", added.Index, ShortID(added)), nil
 }
 
-// printTasks реализует вывод в терминал список задач с преобразованием их статуса в читаемый вид.
-func printTasks(tasks []models.Task) error {
-	var resBuild strings.Builder
-	for _, task := range tasks {
-		var status string
-		switch task.Status {
-		case models.StatusDone:
-			status = "Done"
-		case models.StatusInProgress:
-			status = "In progress"
-		case models.StatusNotDone:
-			status = "Not started"
-		default:
-			status = "Incorrect task status"
+// modifyTask применяет fn к задаче, на которую указывает ссылка пользователя (номер или префикс ID),
+// в актуальном состоянии хранилища. Если fn изменила статус задачи, переход проверяется по настройкам статусов.
+// Если fn завершила повторяющуюся задачу, создается и возвращается ее следующее вхождение.
+// Если задача не найдена или переход не разрешен, хранилище не изменяется.
+func modifyTask(store Store, ref string, fn func(task *models.Task)) (next models.Task, err error) {
+	err = store.Modify(func(list *TaskList) error {
+		i, err := resolveRef(list.Tasks, ref)
+		if err != nil {
+			return err
 		}
-		resBuild.WriteString(fmt.Sprintf("Index: %d\tName: %s\tStatus: %s\n", task.Index, task.Name, status))
-	}
 
-	cmd := exec.Command("less")
-	cmd.Stdin = strings.NewReader(resBuild.String())
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		before := list.Tasks[i]
+		fn(&list.Tasks[i])
 
-	return cmd.Run()
+		err = checkTransition(before.Status, list.Tasks[i].Status)
+		if err != nil {
+			return err
+		}
+		if !isDone(before) {
+			next, _ = recur(list, i, timeNow())
+		}
+		return nil
+	})
+
+	return next, err
 }
 
-// addToFile преобразует полученные объекты типа Task и добавляет обновленный список
-// пользовательских задач в созданный файл.
-func addToFile(allTasks []models.Task) error {
-	tasksJSON, err := json.MarshalIndent(allTasks, "", "\t")
-	if err != nil {
-		return errors.New("error serializing to JSON")
+// updatedMessage возвращает сообщение об обновлении задачи, дополненное сведениями о следующем вхождении,
+// если оно было создано.
+func updatedMessage(next models.Task) string {
+	if next.ID == "" {
+		return "Task updated"
 	}
 
-	err = os.WriteFile(tasksPath, []byte(tasksJSON), 0644)
-	if err != nil {
-		return errors.New("error writing to file")
+	return fmt.Sprintf("Task updated. Next occurrence: task %d due %s", next.Index, dates.Format(next.Due))
+}
+
+// optionalElement возвращает необязательный аргумент по индексу или пустую строку, если он не передан.
+func optionalElement(elements []string, i int) string {
+	if i < len(elements) {
+		return elements[i]
 	}
 
-	return nil
+	return ""
 }
 
-// Add является методом объекта типа Task и реализует добавление новой задачи в список задач. После чего возвращает
-// сообщение о результате действия или ошибку.
-func Add(tasks *[]models.Task, elements []string) (string, error) {
-	newName := strings.ReplaceAll(strings.ReplaceAll(elements[0], "\"", ""), "'", "")
+// trimQuotes убирает кавычки, в которые пользователь заключил аргумент с пробелами.
+func trimQuotes(element string) string {
+	return strings.Trim(element, "\"'")
+}
+
+// Update реализует обновление имени и статуса задачи по указанному пользователем номеру или префиксу ID задачи.
+// Вместо одной задачи можно указать список номеров и диапазонов (3-7,10) или фильтр, тогда все задачи
+// изменяются за одну запись в хранилище.
+// Следующими аргументами могут быть переданы новые срок выполнения, приоритет, изменения тегов
+// (через запятую, "-tag" удаляет тег), проект (none удаляет проект) и описание.
+// После чего возвращает сообщение о результате действия или ошибку.
+func Update(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+
+	status, err := parseStatus(elements[2])
+	if err != nil {
+		return "", err
+	}
+
+	newName := trimQuotes(elements[1])
 	if newName == "" {
 		return "", ErrNameNotExists
 	}
 
-	newTask := models.Task{
-		Index:  (*tasks)[len(*tasks)-1].Index + 1,
-		Name:   newName,
-		Status: models.StatusNotDone,
+	// Проверяем изменения тегов до обращения к хранилищу, чтобы сообщить об ошибке без блокировки файла.
+	tagChanges := optionalElement(elements, 5)
+	_, err = applyTagChanges(nil, tagChanges)
+	if err != nil {
+		return "", err
 	}
+	project := optionalElement(elements, 6)
+	description := strings.TrimSpace(optionalElement(elements, 7))
 
-	*tasks = append(*tasks, newTask)
+	var due time.Time
+	if len(elements) > 3 && elements[3] != "" {
+		due, err = parseDue(elements[3])
+		if err != nil {
+			return "", err
+		}
+	}
 
-	err := addToFile(*tasks)
+	priority := models.Priority(-1)
+	if len(elements) > 4 && elements[4] != "" {
+		priority, err = parsePriority(elements[4])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	modified, next, err := modifyTasks(store, elements[0], func(task *models.Task) {
+		at := timeNow()
+		setStatus(task, status, at)
+		task.Name = newName
+		if !due.IsZero() {
+			task.Due = due
+		}
+		if priority >= 0 {
+			task.Priority = priority
+		}
+		task.Tags, _ = applyTagChanges(task.Tags, tagChanges)
+		switch {
+		case strings.EqualFold(project, "none"):
+			task.Project = ""
+		case project != "":
+			task.Project = normalizeProject(project)
+		}
+		if description != "" {
+			task.Description = description
+		}
+		task.UpdatedAt = at
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return notFoundMessage(elements[0]), nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("addToFile: %w", err)
+		return "", fmt.Errorf("modifyTasks: %w", err)
 	}
 
-	return "Task added", nil
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return updatedTasksMessage(modified, next), nil
 }
 
-// Update является методом объекта типа Task и реализует обновление имени и статуса задачи
-// по указанному пользователем индексу задачи. После чего возвращает сообщение о результате
-// действия или ошибку.
-func Update(tasks *[]models.Task, elements []string) (string, error) {
+// Delete реализует удаление задачи по номеру или префиксу ID в корзину, откуда ее можно восстановить (см. Restore).
+// Вместо одной задачи можно указать список номеров и диапазонов (3-7,10) или фильтр.
+// Вторым аргументом передается режим удаления задачи с подзадачами: cascade удаляет подзадачи,
+// promote переносит их к родителю удаляемой задачи. Без режима задачу можно удалить вместе с подзадачами,
+// только если все они тоже указаны для удаления.
+// После чего возвращает сообщение о результате действия или ошибку.
+func Delete(store Store, tasks *[]models.Task, elements []string) (string, error) {
 	if elements[0] == "" {
 		return "", ErrIndexNotExists
 	}
 
-	index, err := strconv.Atoi(elements[0])
-	if err != nil {
-		return "", errAtoi
-	}
+	targeted, deleted := 0, 0
+	err := store.Modify(func(list *TaskList) error {
+		positions, err := resolveTargets(list.Tasks, elements[0])
+		if err != nil {
+			return err
+		}
 
-	for i, task := range *tasks {
-		if task.Index == index {
-			if elements[2] == "" {
-				return "", ErrStatusNotExists
-			}
-			status, err := strconv.Atoi(elements[2])
-			if err != nil {
-				return "", errAtoi
-			}
-			if status < 0 || status > 2 {
-				return "", errIncorrectStatus
-			}
+		targets := make([]models.Task, 0, len(positions))
+		ids := make(map[string]bool, len(positions))
+		for _, i := range positions {
+			targets = append(targets, list.Tasks[i])
+			ids[list.Tasks[i].ID] = true
+		}
 
-			if elements[1] == "" {
-				return "", ErrNameNotExists
+		targeted, deleted = len(targets), 0
+		for _, target := range targets {
+			// Задача уже удалена вместе с родителем.
+			i := slices.IndexFunc(list.Tasks, func(task models.Task) bool { return task.ID == target.ID })
+			if i == -1 {
+				continue
 			}
 
-			(*tasks)[i].Name = elements[1]
-			(*tasks)[i].Status = models.TaskStatus(status)
+			mode := optionalElement(elements, 1)
+			if mode == "" && allTargeted(descendants(list.Tasks, target.ID), ids) {
+				mode = DeleteCascade
+			}
 
-			err = addToFile(*tasks)
+			n, err := removeTask(list, i, mode)
+			if err != nil && targeted > 1 {
+				return fmt.Errorf("task %d: %w", target.Index, err)
+			}
 			if err != nil {
-				return "", fmt.Errorf("addToFile: %w", err)
+				return err
 			}
-
-			return "Task updated", nil
+			deleted += n
 		}
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return notFoundMessage(elements[0]), nil
 	}
-
-	return "Task not found", nil
-}
-
-// Delete является методом объекта типа Task и реализует удаление задачи по индексу из общего списка задач.
-// После чего возвращает сообщение о результате действия или ошибку.
-func Delete(tasks *[]models.Task, elements []string) (string, error) {
-	if elements[0] == "" {
-		return "", ErrIndexNotExists
+	if err != nil {
+		return "", fmt.Errorf("store.Modify: %w", err)
 	}
 
-	index, err := strconv.Atoi(elements[0])
+	err = refresh(store, tasks)
 	if err != nil {
-		return "", errAtoi
+		return "", fmt.Errorf("refresh: %w", err)
 	}
 
-	for i, task := range *tasks {
-		if task.Index == index {
-			if i == len(*tasks)-1 {
-				*tasks = (*tasks)[:i]
-			} else {
-				*tasks = append((*tasks)[:i], (*tasks)[i+1:]...)
-			}
+	message := "Task moved to trash"
+	if targeted > 1 {
+		message = fmt.Sprintf("%d tasks moved to trash", targeted)
+	}
+	if deleted > targeted {
+		message += fmt.Sprintf(" with %d subtasks", deleted-targeted)
+	}
 
-			err = addToFile(*tasks)
-			if err != nil {
-				return "", fmt.Errorf("addToFile: %w", err)
-			}
+	return message, nil
+}
 
-			return "Task deleted", nil
+// allTargeted сообщает, что все задачи из tasks указаны для удаления.
+func allTargeted(tasks []models.Task, ids map[string]bool) bool {
+	for _, task := range tasks {
+		if !ids[task.ID] {
+			return false
 		}
 	}
 
-	return "Task not found", nil
+	return true
 }
 
-// UpdateStatus является методом объекта типа Task и реализует обновление статуса задачи
-// по указанному пользователем индексу задачи. После чего возвращает сообщение о результате
-// действия или ошибку.
-func UpdateStatus(tasks *[]models.Task, elements []string) (string, error) {
+// UpdateStatus реализует обновление статуса задачи по указанному пользователем номеру или префиксу ID задачи.
+// Вместо одной задачи можно указать список номеров и диапазонов (3-7,10) или фильтр, тогда все задачи
+// изменяются за одну запись в хранилище.
+// Если заблокированная задача переводится в работу, к сообщению добавляется предупреждение.
+// После чего возвращает сообщение о результате действия или ошибку.
+func UpdateStatus(store Store, tasks *[]models.Task, elements []string) (string, error) {
 	if elements[0] == "" {
 		return "", ErrIndexNotExists
 	}
 
-	index, err := strconv.Atoi(elements[0])
+	status, err := parseStatus(elements[1])
 	if err != nil {
-		return "", errAtoi
+		return "", err
 	}
 
-	for i, task := range *tasks {
-		if task.Index == index {
-			if elements[1] == "" {
-				return "", ErrStatusNotExists
-			}
-			status, err := strconv.Atoi(elements[1])
-			if err != nil {
-				return "", errAtoi
-			}
-			if status < 0 || status > 2 {
-				return "", errIncorrectStatus
-			}
+	modified, next, err := modifyTasks(store, elements[0], func(task *models.Task) {
+		setStatus(task, status, timeNow())
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return notFoundMessage(elements[0]), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("modifyTasks: %w", err)
+	}
 
-			(*tasks)[i].Status = models.TaskStatus(status)
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
 
-			err = addToFile(*tasks)
-			if err != nil {
-				return "", fmt.Errorf("addToFile: %w", err)
-			}
+	message := updatedTasksMessage(modified, next)
+	if !isStarted(status) {
+		return message, nil
+	}
 
-			return "Task updated", nil
+	var blocked []models.Task
+	for _, task := range modified {
+		i := slices.IndexFunc(*tasks, func(t models.Task) bool { return t.ID == task.ID })
+		if i != -1 && len(blockers(*tasks, (*tasks)[i])) > 0 {
+			blocked = append(blocked, (*tasks)[i])
 		}
 	}
-
-	return "Task not found", nil
+	switch {
+	case len(blocked) == 0:
+		return message, nil
+	case len(modified) == 1:
+		return fmt.Sprintf("%s. Warning: the task is blocked by unfinished tasks %s", message, blockerIndexes(blockers(*tasks, blocked[0]))), nil
+	}
+	return fmt.Sprintf("%s. Warning: tasks %s are blocked by unfinished tasks", message, blockerIndexes(blocked)), nil
 }
 
-// AllTasks передает в функцию для вывода в терминал список всех существующих задач пользователя.
-func AllTasks(tasks *[]models.Task) error {
-	err := printTasks(*tasks)
+// parseDue разбирает срок выполнения задачи, введенный пользователем, относительно текущего локального времени.
+func parseDue(element string) (time.Time, error) {
+	due, err := dates.Parse(trimQuotes(element), time.Now())
 	if err != nil {
-		return fmt.Errorf("printTasks: %w", err)
+		return time.Time{}, fmt.Errorf("dates.Parse: %w", err)
 	}
-	return nil
+
+	return due, nil
 }
 
-// DoneTasks передает в функцию для вывода в терминал список всех существующих задач пользователя
-// со статусом "Выполнено".
-func DoneTasks(tasks *[]models.Task) error {
-	var result []models.Task
+// SetDue реализует установку срока выполнения задачи по номеру или префиксу ID задачи.
+// Значение none удаляет срок. После чего возвращает сообщение о результате действия или ошибку.
+func SetDue(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+	if elements[1] == "" {
+		return "", ErrDueNotExists
+	}
 
-	for _, task := range *tasks {
-		if task.Status == models.StatusDone {
-			result = append(result, task)
+	var due time.Time
+	if strings.ToLower(elements[1]) != "none" {
+		var err error
+		due, err = parseDue(elements[1])
+		if err != nil {
+			return "", err
 		}
 	}
 
-	err := printTasks(result)
+	_, err := modifyTask(store, elements[0], func(task *models.Task) {
+		task.Due = due
+		task.UpdatedAt = timeNow()
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
 	if err != nil {
-		return fmt.Errorf("printTasks: %w", err)
+		return "", fmt.Errorf("modifyTask: %w", err)
 	}
-	return nil
-}
 
-// NotDoneTasks передает в функцию для вывода в терминал список всех существующих задач пользователя
-// со статусом "Не начато".
-func NotDoneTasks(tasks *[]models.Task) error {
-	var result []models.Task
-
-	for _, task := range *tasks {
-		if task.Status == models.StatusNotDone {
-			result = append(result, task)
-		}
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
 	}
 
-	err := printTasks(result)
-	if err != nil {
-		return fmt.Errorf("printTasks: %w", err)
+	if due.IsZero() {
+		return "Due date removed", nil
 	}
-	return nil
+
+	return fmt.Sprintf("Task due %s", dates.Format(due)), nil
 }
 
-// InProgressTasks передает в функцию для вывода в терминал список всех существующих задач пользователя
-// со статусом "В процессе".
-func InProgressTasks(tasks *[]models.Task) error {
-	var result []models.Task
+// SetPriority реализует установку приоритета задачи по номеру или префиксу ID задачи.
+// После чего возвращает сообщение о результате действия или ошибку.
+func SetPriority(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+	if elements[1] == "" {
+		return "", ErrPriorityNotExists
+	}
 
-	for _, task := range *tasks {
-		if task.Status == models.StatusInProgress {
-			result = append(result, task)
-		}
+	priority, err := parsePriority(elements[1])
+	if err != nil {
+		return "", err
 	}
 
-	err := printTasks(result)
+	_, err = modifyTask(store, elements[0], func(task *models.Task) {
+		task.Priority = priority
+		task.UpdatedAt = timeNow()
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
 	if err != nil {
-		return fmt.Errorf("printTasks: %w", err)
+		return "", fmt.Errorf("modifyTask: %w", err)
 	}
-	return nil
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return fmt.Sprintf("Task priority set to %s", priorityName(priority)), nil
 }
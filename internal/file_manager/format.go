@@ -0,0 +1,280 @@
+package filemanager
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	// FormatText — вывод для чтения в терминале, используется по умолчанию.
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+)
+
+var ErrInvalidFormat error = errors.New("an invalid output format was passed")
+
+// formats перечисляет форматы вывода списков задач.
+var formats = []string{FormatText, FormatJSON, FormatJSONL, FormatCSV, FormatTSV, FormatYAML, FormatMarkdown}
+
+// formatAliases — короткие названия форматов вывода.
+var formatAliases = map[string]string{
+	"md":  FormatMarkdown,
+	"yml": FormatYAML,
+}
+
+// parseFormat приводит название формата вывода к одному из formats. Пустое название означает FormatText.
+func parseFormat(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return FormatText, nil
+	}
+	if alias, ok := formatAliases[name]; ok {
+		return alias, nil
+	}
+	if !slices.Contains(formats, name) {
+		return "", fmt.Errorf("%w: %s (available: %s)", ErrInvalidFormat, name, strings.Join(formats, ", "))
+	}
+
+	return name, nil
+}
+
+// timeField возвращает значение колонки с датой или nil, если дата не задана.
+func timeField(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
+
+// stringField возвращает значение текстовой колонки или nil, если текст пустой.
+func stringField(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// recordField — значение одной колонки задачи.
+type recordField struct {
+	name  string
+	value any
+}
+
+// record — значения колонок одной задачи в порядке колонок списка.
+type record []recordField
+
+// MarshalJSON записывает запись как JSON-объект, сохраняя порядок колонок.
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(plainValue(field.value))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// plainValue приводит даты к строкам RFC 3339, чтобы все форматы выводили их одинаково.
+func plainValue(value any) any {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	return value
+}
+
+// cellText возвращает значение колонки в виде текста для табличных форматов: списки записываются через запятую,
+// отсутствующее значение — пустой строкой.
+func cellText(value any) string {
+	switch v := plainValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ",")
+	case []int:
+		parts := make([]string, 0, len(v))
+		for _, n := range v {
+			parts = append(parts, strconv.Itoa(n))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// taskRecords возвращает записи задач с колонками cols. Задачи идут в том же порядке, что и в текстовом выводе:
+// подзадачи под своими родителями, по разделам группировки. Если задачи сгруппированы, первой колонкой
+// записывается название раздела.
+func taskRecords(all, tasks []models.Task, groups []taskGroup, cols []column, at time.Time) []record {
+	var records []record
+	add := func(group *taskGroup, tasks []models.Task) {
+		for _, item := range buildTree(tasks) {
+			r := make(record, 0, len(cols)+1)
+			if group != nil {
+				r = append(r, recordField{"group", group.title})
+			}
+			for _, c := range cols {
+				r = append(r, recordField{c.name, c.field(all, item.task, at)})
+			}
+			records = append(records, r)
+		}
+	}
+
+	if groups == nil {
+		add(nil, tasks)
+	}
+	for i := range groups {
+		add(&groups[i], groups[i].tasks)
+	}
+
+	return records
+}
+
+// recordHeader возвращает названия колонок записей для заголовка табличных форматов.
+func recordHeader(cols []column, grouped bool) []string {
+	header := make([]string, 0, len(cols)+1)
+	if grouped {
+		header = append(header, "group")
+	}
+	for _, c := range cols {
+		header = append(header, c.name)
+	}
+
+	return header
+}
+
+// writeFormatted записывает записи задач в w в машиночитаемом формате: json (массив объектов), jsonl (объект
+// в строке), csv и tsv (с заголовком), yaml (список) или markdown (таблица).
+func writeFormatted(w io.Writer, format string, header []string, records []record) error {
+	switch format {
+	case FormatJSON:
+		if records == nil {
+			records = []record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("json.MarshalIndent: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+
+	case FormatJSONL:
+		for _, r := range records {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+			_, err = fmt.Fprintf(w, "%s\n", data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatCSV, FormatTSV:
+		cw := csv.NewWriter(w)
+		if format == FormatTSV {
+			cw.Comma = '\t'
+		}
+		rows := [][]string{header}
+		for _, r := range records {
+			row := make([]string, 0, len(r))
+			for _, field := range r {
+				row = append(row, cellText(field.value))
+			}
+			rows = append(rows, row)
+		}
+		return cw.WriteAll(rows)
+
+	case FormatYAML:
+		return writeYAML(w, records)
+
+	case FormatMarkdown:
+		return writeMarkdown(w, header, records)
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+}
+
+// writeYAML записывает записи списком YAML. Значения записываются в JSON-виде, который является
+// допустимым YAML, поэтому строки не требуют отдельного экранирования.
+func writeYAML(w io.Writer, records []record) error {
+	if len(records) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+
+	var b strings.Builder
+	for _, r := range records {
+		for i, field := range r {
+			value, err := json.Marshal(plainValue(field.value))
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", prefix, field.name, value))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdown записывает записи таблицей Markdown. Символы | и переводы строк в значениях экранируются.
+func writeMarkdown(w io.Writer, header []string, records []record) error {
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+	for _, r := range records {
+		b.WriteString("|")
+		for _, field := range r {
+			b.WriteString(" " + escape.Replace(cellText(field.value)) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
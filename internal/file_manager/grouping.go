@@ -0,0 +1,120 @@
+package filemanager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var ErrInvalidGroupKey error = errors.New("an invalid group key was passed")
+
+// taskGroup — раздел списка задач с заголовком.
+type taskGroup struct {
+	title string
+	tasks []models.Task
+}
+
+// groupKey описывает группировку списка задач.
+type groupKey struct {
+	// labels возвращает разделы, в которые попадает задача. Пустая строка означает, что значения у задачи нет.
+	labels func(task models.Task) []string
+	// compare упорядочивает разделы.
+	compare func(a, b string) int
+	// missing — заголовок раздела задач без значения. Этот раздел выводится последним.
+	missing string
+}
+
+// groupKeys сопоставляет ключам группировки способы разбиения задач на разделы.
+var groupKeys = map[string]groupKey{
+	"status": {
+		labels: func(task models.Task) []string { return []string{string(task.Status)} },
+		compare: func(a, b string) int {
+			return cmp.Compare(statusOrder(models.TaskStatus(a)), statusOrder(models.TaskStatus(b)))
+		},
+		missing: "No status",
+	},
+	"project": {
+		labels:  func(task models.Task) []string { return []string{task.Project} },
+		compare: strings.Compare,
+		missing: "No project",
+	},
+	// Задача с несколькими тегами выводится в разделе каждого тега.
+	"tag": {
+		labels: func(task models.Task) []string {
+			if len(task.Tags) == 0 {
+				return []string{""}
+			}
+			return task.Tags
+		},
+		compare: strings.Compare,
+		missing: "No tags",
+	},
+	// Разделы по неделям срока выполнения с понедельника по воскресенье. Заголовок начинается с даты,
+	// поэтому разделы упорядочиваются сравнением строк.
+	"due": {
+		labels: func(task models.Task) []string {
+			if task.Due.IsZero() {
+				return []string{""}
+			}
+			return []string{dueWeek(task.Due.Local())}
+		},
+		compare: strings.Compare,
+		missing: "No due date",
+	},
+}
+
+// dueWeek возвращает заголовок недели, к которой относится срок выполнения: "2025-12-29 - 2026-01-04".
+func dueWeek(due time.Time) string {
+	monday := due.AddDate(0, 0, -(int(due.Weekday())+6)%7)
+	return fmt.Sprintf("%s - %s", monday.Format(time.DateOnly), monday.AddDate(0, 0, 6).Format(time.DateOnly))
+}
+
+// groupTasks разбивает уже отсортированные задачи на разделы по ключу группировки. Порядок задач внутри
+// раздела сохраняется. Для пустого ключа возвращается nil.
+func groupTasks(tasks []models.Task, key string) ([]taskGroup, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	group, ok := groupKeys[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (available: status, project, tag, due)", ErrInvalidGroupKey, key)
+	}
+
+	byLabel := make(map[string][]models.Task)
+	for _, task := range tasks {
+		for _, label := range group.labels(task) {
+			byLabel[label] = append(byLabel[label], task)
+		}
+	}
+
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	slices.SortFunc(labels, func(a, b string) int {
+		switch {
+		case a == "":
+			return 1
+		case b == "":
+			return -1
+		}
+		return group.compare(a, b)
+	})
+
+	groups := make([]taskGroup, 0, len(labels))
+	for _, label := range labels {
+		title := label
+		if label == "" {
+			title = group.missing
+		}
+		groups = append(groups, taskGroup{title: title, tasks: byLabel[label]})
+	}
+
+	return groups, nil
+}
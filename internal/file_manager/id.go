@@ -0,0 +1,82 @@
+package filemanager
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	// ShortIDLength — количество символов ID, которое выводится в списках задач.
+	ShortIDLength = 8
+	// MinIDPrefixLength — наименьшая длина префикса ID, по которому ищется задача. Более короткие префиксы
+	// совпадают со слишком многими ID и легко путаются со словами из названий задач.
+	MinIDPrefixLength = 4
+)
+
+var (
+	ErrAmbiguousID   error = errors.New("the passed ID prefix matches several tasks")
+	ErrShortIDPrefix error = fmt.Errorf("an ID prefix must have at least %d characters", MinIDPrefixLength)
+)
+
+// newID генерирует случайный UUID версии 4 в каноническом текстовом виде.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ShortID возвращает сокращенный ID задачи для вывода в терминал.
+func ShortID(task models.Task) string {
+	if len(task.ID) <= ShortIDLength {
+		return task.ID
+	}
+
+	return task.ID[:ShortIDLength]
+}
+
+// resolveRef находит позицию задачи по ссылке пользователя. Ссылкой может быть номер задачи
+// или префикс ее ID длиной не меньше MinIDPrefixLength. Номер имеет приоритет: префикс ID проверяется,
+// только если задачи с таким номером нет.
+func resolveRef(tasks []models.Task, ref string) (int, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return -1, ErrIndexNotExists
+	}
+
+	index, err := strconv.Atoi(ref)
+	if err == nil {
+		if i := findTask(tasks, index); i != -1 {
+			return i, nil
+		}
+	}
+	if len(ref) < MinIDPrefixLength {
+		if err == nil {
+			return -1, ErrTaskNotFound
+		}
+		return -1, fmt.Errorf("%w: %s", ErrShortIDPrefix, ref)
+	}
+
+	found := -1
+	for i, task := range tasks {
+		if task.ID != "" && strings.HasPrefix(task.ID, ref) {
+			if found != -1 {
+				return -1, ErrAmbiguousID
+			}
+			found = i
+		}
+	}
+	if found == -1 {
+		return -1, ErrTaskNotFound
+	}
+
+	return found, nil
+}
@@ -0,0 +1,34 @@
+package filemanager
+
+import (
+	"testing"
+)
+
+func TestStoreIndexesAreNotReused(t *testing.T) {
+	for storeName, newStore := range testStores() {
+		t.Run(storeName, func(t *testing.T) {
+			store := newStore(t)
+			addTasks(t, store, "a", "b")
+
+			err := store.Modify(func(list *TaskList) error {
+				list.Tasks = list.Tasks[:1]
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("store.Modify: %v", err)
+			}
+			addTasks(t, store, "c")
+
+			tasks, err := store.List()
+			if err != nil {
+				t.Fatalf("store.List: %v", err)
+			}
+			if got := tasks[len(tasks)-1].Index; got != 3 {
+				t.Errorf("index of the new task = %d, want 3", got)
+			}
+			if tasks[0].ID == "" || tasks[0].ID == tasks[1].ID {
+				t.Errorf("tasks must have unique IDs: %q, %q", tasks[0].ID, tasks[1].ID)
+			}
+		})
+	}
+}
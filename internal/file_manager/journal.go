@@ -0,0 +1,334 @@
+package filemanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/atomicfile"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	journalSuffix = ".journal"
+	// maxJournalOperations — количество последних операций, которые можно отменить.
+	maxJournalOperations = 100
+)
+
+var (
+	ErrNothingToUndo    error = errors.New("there are no operations to undo")
+	ErrNothingToRedo    error = errors.New("there are no undone operations to redo")
+	ErrJournalConflict  error = errors.New("the tasks were changed outside the tracker, the operation cannot be applied")
+	ErrInvalidStepCount error = errors.New("the number of operations must be a positive integer")
+)
+
+// TaskChange — состояние задачи до и после операции. Before пустой у добавленной задачи, After — у удаленной.
+type TaskChange struct {
+	Before *models.Task `json:"before,omitempty"`
+	After  *models.Task `json:"after,omitempty"`
+}
+
+// Operation — одно изменение хранилища: время и затронутые задачи.
+type Operation struct {
+	At      time.Time    `json:"at"`
+	Changes []TaskChange `json:"changes"`
+}
+
+// journal — журнал операций. Операции до Position применены и могут быть отменены,
+// операции после Position отменены и могут быть применены повторно.
+type journal struct {
+	Operations []Operation `json:"operations"`
+	Position   int         `json:"position"`
+}
+
+// Description возвращает описание операции для вывода в терминал: added task 5 "Name", trashed task 3 "Name".
+func (op Operation) Description() string {
+	const shown = 3
+
+	parts := make([]string, 0, shown+1)
+	for i, change := range op.Changes {
+		if i == shown {
+			parts = append(parts, fmt.Sprintf("and %d more", len(op.Changes)-shown))
+			break
+		}
+
+		task := change.After
+		if task == nil {
+			task = change.Before
+		}
+		parts = append(parts, fmt.Sprintf("%s task %d %q", changeAction(change), task.Index, task.Name))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// changeAction возвращает действие, которое описывает изменение задачи: добавление, изменение, перенос
+// в архив или корзину, восстановление или окончательное удаление.
+func changeAction(change TaskChange) string {
+	switch {
+	case change.Before == nil:
+		return ActionAdded
+	case change.After == nil:
+		return ActionDeleted
+	case !change.Before.DeletedAt.Equal(change.After.DeletedAt) && !change.After.DeletedAt.IsZero():
+		return ActionTrashed
+	case !change.Before.ArchivedAt.Equal(change.After.ArchivedAt) && !change.After.ArchivedAt.IsZero():
+		return ActionArchived
+	case isArchived(*change.Before) && !isArchived(*change.After):
+		return ActionRestored
+	}
+
+	return ActionUpdated
+}
+
+// clone возвращает копию журнала, которая не меняется при записи в журнал новых операций.
+func (j journal) clone() journal {
+	return journal{Operations: slices.Clone(j.Operations), Position: j.Position}
+}
+
+// record добавляет операцию в журнал. Отмененные операции после текущей позиции отбрасываются,
+// самые старые операции удаляются, чтобы журнал не превышал maxJournalOperations.
+func (j *journal) record(op Operation) {
+	j.Operations = append(j.Operations[:j.Position], op)
+	if len(j.Operations) > maxJournalOperations {
+		j.Operations = j.Operations[len(j.Operations)-maxJournalOperations:]
+	}
+	j.Position = len(j.Operations)
+}
+
+// undo отменяет последнюю примененную операцию в списке задач.
+func (j *journal) undo(list *TaskList) (Operation, error) {
+	if j.Position == 0 {
+		return Operation{}, ErrNothingToUndo
+	}
+
+	op := j.Operations[j.Position-1]
+	err := applyChanges(list, op.Changes, true)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	j.Position--
+	return op, nil
+}
+
+// redo повторно применяет последнюю отмененную операцию в списке задач.
+func (j *journal) redo(list *TaskList) (Operation, error) {
+	if j.Position == len(j.Operations) {
+		return Operation{}, ErrNothingToRedo
+	}
+
+	op := j.Operations[j.Position]
+	err := applyChanges(list, op.Changes, false)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	j.Position++
+	return op, nil
+}
+
+// diffTasks сравнивает задачи до и после изменения по ID и возвращает добавленные, измененные и удаленные задачи.
+func diffTasks(before, after []models.Task) []TaskChange {
+	old := make(map[string]models.Task, len(before))
+	for _, task := range before {
+		old[task.ID] = task
+	}
+
+	var changes []TaskChange
+	for _, task := range after {
+		prev, ok := old[task.ID]
+		delete(old, task.ID)
+		switch {
+		case !ok:
+			changes = append(changes, TaskChange{After: &task})
+		case !sameTask(prev, task):
+			changes = append(changes, TaskChange{Before: &prev, After: &task})
+		}
+	}
+
+	for _, task := range before {
+		if _, ok := old[task.ID]; ok {
+			changes = append(changes, TaskChange{Before: &task})
+		}
+	}
+
+	return changes
+}
+
+// sameTask сравнивает задачи по их JSON-представлению, в котором они хранятся в файле и журнале.
+func sameTask(a, b models.Task) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+// applyChanges переводит задачи в состояние после операции или, если undo, в состояние до нее.
+// Перед изменением проверяется, что задачи находятся в ожидаемом состоянии; иначе список не изменяется
+// и возвращается ErrJournalConflict.
+func applyChanges(list *TaskList, changes []TaskChange, undo bool) error {
+	tasks := list.all()
+
+	for i := range changes {
+		// Изменения отменяются в обратном порядке.
+		change := changes[i]
+		if undo {
+			change = changes[len(changes)-1-i]
+		}
+
+		from, to := change.Before, change.After
+		if undo {
+			from, to = to, from
+		}
+
+		id := changeID(change)
+		pos := -1
+		for k, task := range tasks {
+			if task.ID == id {
+				pos = k
+				break
+			}
+		}
+
+		switch {
+		case from == nil && pos != -1, from != nil && (pos == -1 || !sameTask(tasks[pos], *from)):
+			return fmt.Errorf("%w: task %s", ErrJournalConflict, changeRef(change))
+		case to == nil:
+			tasks = append(tasks[:pos], tasks[pos+1:]...)
+		case pos == -1:
+			tasks = insertTask(tasks, *to)
+		default:
+			tasks[pos] = *to
+		}
+	}
+
+	list.split(tasks)
+	list.normalize()
+	return nil
+}
+
+// insertTask возвращает восстановленную задачу на место по порядку номеров.
+func insertTask(tasks []models.Task, task models.Task) []models.Task {
+	for i, existing := range tasks {
+		if existing.Index > task.Index {
+			return append(tasks[:i], append([]models.Task{task}, tasks[i:]...)...)
+		}
+	}
+
+	return append(tasks, task)
+}
+
+// changeID возвращает ID задачи, которую затрагивает изменение.
+func changeID(change TaskChange) string {
+	if change.After != nil {
+		return change.After.ID
+	}
+
+	return change.Before.ID
+}
+
+// changeRef возвращает номер задачи, которую затрагивает изменение, для сообщений об ошибках.
+func changeRef(change TaskChange) string {
+	if change.After != nil {
+		return strconv.Itoa(change.After.Index)
+	}
+
+	return strconv.Itoa(change.Before.Index)
+}
+
+// journalPath возвращает путь к журналу операций файла задач.
+func journalPath(path string) string {
+	return path + journalSuffix
+}
+
+// readJournal считывает журнал операций. Если журнала нет, возвращается пустой журнал.
+func readJournal(path string) (journal, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return journal{}, nil
+	}
+	if err != nil {
+		return journal{}, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var j journal
+	err = json.Unmarshal(data, &j)
+	if err != nil {
+		return journal{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	j.Position = min(max(j.Position, 0), len(j.Operations))
+
+	return j, nil
+}
+
+// writeJournal атомарно записывает журнал операций.
+func writeJournal(path string, j journal) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	err = atomicfile.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// Undo реализует отмену последних операций с задачами. Аргументом можно передать количество операций.
+// Операции хранятся в журнале рядом с файлом задач, поэтому их можно отменить и после перезапуска.
+// После чего возвращает описание отмененных операций или ошибку.
+func Undo(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	return stepJournal(store, tasks, optionalElement(elements, 0), store.Undo, "Undone")
+}
+
+// Redo реализует повторное применение последних отмененных операций. Аргументом можно передать
+// количество операций. Новое изменение задач после отмены очищает список операций для повтора.
+// После чего возвращает описание примененных операций или ошибку.
+func Redo(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	return stepJournal(store, tasks, optionalElement(elements, 0), store.Redo, "Redone")
+}
+
+// stepJournal выполняет step count раз и возвращает описание выполненных шагов. Если часть шагов
+// выполнена до ошибки, ошибка добавляется к описанию выполненных шагов.
+func stepJournal(store Store, tasks *[]models.Task, count string, step func() (Operation, error), verb string) (string, error) {
+	steps := 1
+	if count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("%w: %s", ErrInvalidStepCount, count)
+		}
+		steps = n
+	}
+
+	var lines []string
+	var stepErr error
+	for range steps {
+		op, err := step()
+		if err != nil {
+			stepErr = err
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", verb, op.Description()))
+	}
+	if len(lines) == 0 {
+		return "", stepErr
+	}
+	if stepErr != nil {
+		lines = append(lines, stepErr.Error())
+	}
+
+	err := refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,101 @@
+package filemanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestStoreUndoRedo(t *testing.T) {
+	type step struct {
+		action  string
+		wantErr error
+		want    []string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "undo and redo",
+			steps: []step{
+				{action: "undo", want: []string{"a"}},
+				{action: "undo", want: []string{}},
+				{action: "undo", wantErr: ErrNothingToUndo, want: []string{}},
+				{action: "redo", want: []string{"a"}},
+				{action: "redo", want: []string{"a", "b"}},
+				{action: "redo", wantErr: ErrNothingToRedo, want: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "new change clears redo",
+			steps: []step{
+				{action: "undo", want: []string{"a"}},
+				{action: "add", want: []string{"a", "c"}},
+				{action: "redo", wantErr: ErrNothingToRedo, want: []string{"a", "c"}},
+				{action: "undo", want: []string{"a"}},
+			},
+		},
+	}
+
+	for storeName, newStore := range testStores() {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				store := newStore(t)
+				addTasks(t, store, "a")
+				addTasks(t, store, "b")
+
+				for i, s := range tt.steps {
+					var err error
+					switch s.action {
+					case "undo":
+						_, err = store.Undo()
+					case "redo":
+						_, err = store.Redo()
+					case "add":
+						addTasks(t, store, "c")
+					}
+					if !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d (%s): error = %v, want %v", i, s.action, err, s.wantErr)
+					}
+					if got := taskNames(t, store); !slices.Equal(got, s.want) {
+						t.Fatalf("step %d (%s): tasks = %v, want %v", i, s.action, got, s.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestUndoStatusChange(t *testing.T) {
+	for storeName, newStore := range testStores() {
+		t.Run(storeName, func(t *testing.T) {
+			store := newStore(t)
+			addTasks(t, store, "a")
+
+			var tasks []models.Task
+			_, err := UpdateStatus(store, &tasks, []string{"1", "done"})
+			if err != nil {
+				t.Fatalf("UpdateStatus: %v", err)
+			}
+			if tasks[0].Status != "done" || tasks[0].CompletedAt.IsZero() {
+				t.Fatalf("task after UpdateStatus = %+v, want a completed task", tasks[0])
+			}
+
+			_, err = store.Undo()
+			if err != nil {
+				t.Fatalf("store.Undo: %v", err)
+			}
+			task, err := store.Get(1)
+			if err != nil {
+				t.Fatalf("store.Get: %v", err)
+			}
+			if task.Status != initialStatus() || !task.CompletedAt.IsZero() {
+				t.Errorf("task after Undo = %+v, want the initial status without completion time", task)
+			}
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package filemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/atomicfile"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/watcher"
+)
+
+const (
+	lockSuffix = ".lock"
+)
+
+// jsonStore хранит задачи в JSON-файле.
+type jsonStore struct {
+	location Location
+	path     string
+}
+
+// NewJSONStore создает хранилище задач в JSON-файле по указанному расположению.
+// Если файла нет, то он будет создан, а файл старой версии будет переведен в текущую.
+func NewJSONStore(location Location) (*jsonStore, error) {
+	err := CreateFile(location.Path)
+	if err != nil {
+		return nil, fmt.Errorf("CreateFile: %w", err)
+	}
+
+	store := &jsonStore{
+		location: location,
+		path:     location.Path,
+	}
+
+	err = store.migrateFile()
+	if err != nil {
+		return nil, fmt.Errorf("migrateFile: %w", err)
+	}
+
+	return store, nil
+}
+
+// CreateFile проверяет наличие файла по указанному пути.
+// Если его нет, то он будет создан вместе с недостающими директориями.
+func CreateFile(path string) error {
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil
+
+	} else if os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create file: %w", err)
+		}
+
+		err = file.Close()
+		if err != nil {
+			return fmt.Errorf("close file: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "File '%s' created\n", path)
+
+	} else {
+		return fmt.Errorf("file check: %w", err)
+	}
+
+	return nil
+}
+
+// getAllTasks реализует считывание всех задач из файла, преобразует их из JSON в объекты типа Task и возвращает их
+// вместе со счетчиком номеров. Если основной файл поврежден, задачи считываются из резервной копии
+// с предупреждением в терминал.
+func getAllTasks(path string) (TaskList, error) {
+	allTasks, err := readTasks(path)
+	if err == nil {
+		return allTasks, nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+		return TaskList{}, err
+	}
+
+	backupTasks, backupErr := readTasks(backupPath(path))
+	if backupErr != nil {
+		return TaskList{}, fmt.Errorf("%w (backup: %v)", err, backupErr)
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: '%s' is corrupted (%v), tasks were loaded from backup '%s'\n",
+		path, err, backupPath(path))
+
+	return backupTasks, nil
+}
+
+// readTasks считывает и разбирает один файл задач без обращения к резервной копии.
+// Файлы старых версий приводятся к текущей версии в памяти.
+func readTasks(path string) (TaskList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TaskList{}, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	file, _, err := decodeFile(data, workflow)
+	if err != nil {
+		return TaskList{}, fmt.Errorf("decodeFile: %w", err)
+	}
+
+	return file.taskList(), nil
+}
+
+// addToFile преобразует полученные объекты типа Task и атомарно записывает обновленный список
+// пользовательских задач в файл. Предыдущая корректная версия файла сохраняется рядом с расширением .bak.
+// Файл, записанный более новой версией приложения, не перезаписывается.
+func addToFile(path string, allTasks TaskList) error {
+	tasksJSON, err := encodeFile(allTasks)
+	if err != nil {
+		return errors.New("error serializing to JSON")
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	if version, err := fileVersion(existing); err == nil && version > currentVersion {
+		return fmt.Errorf("%w: file version %d, supported version %d", ErrNewerVersion, version, currentVersion)
+	}
+
+	err = backupFile(path, existing)
+	if err != nil {
+		return fmt.Errorf("backupFile: %w", err)
+	}
+
+	err = atomicfile.WriteFile(path, tasksJSON, 0644)
+	if err != nil {
+		return fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// backupFile сохраняет текущее содержимое файла задач в резервную копию.
+// Пустой или поврежденный файл не копируется, чтобы не затереть последнюю корректную резервную копию.
+func backupFile(path string, data []byte) error {
+	if len(data) == 0 || !json.Valid(data) {
+		return nil
+	}
+
+	return atomicfile.WriteFile(backupPath(path), data, 0644)
+}
+
+// migrateFile под эксклюзивной блокировкой переводит файл задач старой версии в текущую.
+// Перед миграцией исходный файл сохраняется в резервную копию с номером старой версии (tasks.json.v0.bak).
+// Резервная копия .bak также переводится в текущую версию: миграция присваивает задачам без ID новые ID,
+// и без записи результата задачи получали бы другие ID при каждом чтении.
+func (s *jsonStore) migrateFile() error {
+	lock, err := lockFile(s.lockPath(), true)
+	if err != nil {
+		return fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	file, version, err := decodeFile(data, workflow)
+	if err != nil || version >= currentVersion {
+		// Поврежденный файл не мигрируется: при чтении задачи будут взяты из резервной копии.
+		return migrateBackup(s.path)
+	}
+
+	err = atomicfile.WriteFile(fmt.Sprintf("%s.v%d%s", s.path, version, backupSuffix), data, 0644)
+	if err != nil {
+		return fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	migrated, err := encodeFile(file.taskList())
+	if err != nil {
+		return fmt.Errorf("encodeFile: %w", err)
+	}
+
+	err = addToFile(s.path, file.taskList())
+	if err != nil {
+		return fmt.Errorf("addToFile: %w", err)
+	}
+
+	// addToFile сохранила в резервную копию исходный файл; она заменяется переведенным,
+	// чтобы у задач из резервной копии были те же ID, что и в файле задач.
+	err = backupFile(s.path, migrated)
+	if err != nil {
+		return fmt.Errorf("backupFile: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "File '%s' migrated from version %d to version %d\n", s.path, version, currentVersion)
+	return nil
+}
+
+// migrateBackup переводит резервную копию файла задач старой версии в текущую.
+// Поврежденная резервная копия и копия текущей или более новой версии не изменяются.
+func migrateBackup(path string) error {
+	data, err := os.ReadFile(backupPath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	file, version, err := decodeFile(data, workflow)
+	if err != nil || version >= currentVersion {
+		return nil
+	}
+
+	migrated, err := encodeFile(file.taskList())
+	if err != nil {
+		return fmt.Errorf("encodeFile: %w", err)
+	}
+
+	err = atomicfile.WriteFile(backupPath(path), migrated, 0644)
+	if err != nil {
+		return fmt.Errorf("atomicfile.WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// journalPath возвращает путь к журналу операций с задачами.
+func (s *jsonStore) journalPath() string {
+	return journalPath(s.path)
+}
+
+// archivePath возвращает путь к файлу архива.
+func (s *jsonStore) archivePath() string {
+	return archivePath(s.path)
+}
+
+// auditPath возвращает путь к журналу аудита.
+func (s *jsonStore) auditPath() string {
+	return auditPath(s.path)
+}
+
+// lockPath возвращает путь к lock-файлу, через который синхронизируются процессы.
+func (s *jsonStore) lockPath() string {
+	return s.path + lockSuffix
+}
+
+// Load под разделяемой блокировкой считывает все задачи из файла.
+func (s *jsonStore) Load() ([]models.Task, error) {
+	lock, err := lockFile(s.lockPath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	list, err := getAllTasks(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("getAllTasks: %w", err)
+	}
+
+	return list.Tasks, nil
+}
+
+// Save под эксклюзивной блокировкой перезаписывает файл переданным списком задач.
+// Счетчик номеров и архив сохраняются из текущей версии файлов.
+func (s *jsonStore) Save(tasks []models.Task) error {
+	lock, err := lockFile(s.lockPath(), true)
+	if err != nil {
+		return fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	before := list.clone()
+	list.Tasks = tasks
+
+	return s.write(before, list)
+}
+
+// Modify под эксклюзивной блокировкой перечитывает файл задач и архив, передает актуальные задачи в fn
+// и записывает результат. Если fn вернула ошибку, файлы не изменяются.
+func (s *jsonStore) Modify(fn func(list *TaskList) error) error {
+	lock, err := lockFile(s.lockPath(), true)
+	if err != nil {
+		return fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	before := list.clone()
+	err = fn(&list)
+	if err != nil {
+		return err
+	}
+
+	return s.write(before, list)
+}
+
+// read считывает задачи вместе с архивом. Вызывается под блокировкой.
+func (s *jsonStore) read() (TaskList, error) {
+	list, err := getAllTasks(s.path)
+	if err != nil {
+		return TaskList{}, fmt.Errorf("getAllTasks: %w", err)
+	}
+
+	list.Archived, err = readArchive(s.archivePath())
+	if err != nil {
+		return TaskList{}, fmt.Errorf("readArchive: %w", err)
+	}
+
+	return list, nil
+}
+
+// writeTasks записывает задачи в файл, а архив — только если он изменился относительно before.
+// Вызывается под эксклюзивной блокировкой.
+func (s *jsonStore) writeTasks(before, list TaskList) error {
+	err := addToFile(s.path, list)
+	if err != nil {
+		return fmt.Errorf("addToFile: %w", err)
+	}
+
+	if slices.EqualFunc(before.Archived, list.Archived, sameTask) {
+		return nil
+	}
+
+	err = writeArchive(s.archivePath(), list.Archived)
+	if err != nil {
+		return fmt.Errorf("writeArchive: %w", err)
+	}
+
+	return nil
+}
+
+// write записывает задачи и добавляет изменение относительно before в журнал операций и журнал аудита.
+// Вызывается под эксклюзивной блокировкой.
+func (s *jsonStore) write(before, list TaskList) error {
+	changes := diffTasks(before.all(), list.all())
+	if len(changes) == 0 {
+		return s.writeTasks(before, list)
+	}
+
+	prev, err := readJournal(s.journalPath())
+	if err != nil {
+		return fmt.Errorf("readJournal: %w", err)
+	}
+	j := prev.clone()
+	at := timeNow()
+	j.record(Operation{At: at, Changes: changes})
+
+	return s.commit(before, list, prev, j, auditEntries(changes, at, ""))
+}
+
+// commit записывает изменение задач вместе с журналом операций j и записями аудита entries. Журналы
+// записываются первыми, файл задач — последним, поэтому каждое записанное изменение задач есть в журнале.
+// Если файл задач записать не удалось, журнал операций возвращается к prev, а добавленные записи аудита удаляются.
+// Вызывается под эксклюзивной блокировкой.
+func (s *jsonStore) commit(before, list TaskList, prev, j journal, entries []AuditEntry) error {
+	size, err := auditSize(s.auditPath())
+	if err != nil {
+		return fmt.Errorf("auditSize: %w", err)
+	}
+
+	err = writeJournal(s.journalPath(), j)
+	if err != nil {
+		return fmt.Errorf("writeJournal: %w", err)
+	}
+
+	err = appendAudit(s.auditPath(), entries)
+	if err == nil {
+		err = s.writeTasks(before, list)
+	}
+	if err == nil {
+		return nil
+	}
+
+	rollbackErr := writeJournal(s.journalPath(), prev)
+	if rollbackErr == nil {
+		rollbackErr = truncateAudit(s.auditPath(), size)
+	}
+	if rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("rollback: %w", rollbackErr))
+	}
+
+	return err
+}
+
+// Undo под эксклюзивной блокировкой отменяет последнюю операцию из журнала и возвращает ее.
+func (s *jsonStore) Undo() (Operation, error) {
+	return s.step((*journal).undo, "undo")
+}
+
+// Redo под эксклюзивной блокировкой повторно применяет последнюю отмененную операцию из журнала и возвращает ее.
+func (s *jsonStore) Redo() (Operation, error) {
+	return s.step((*journal).redo, "redo")
+}
+
+// step применяет к актуальным задачам шаг по журналу операций, записывает задачи и журнал
+// и добавляет изменение в журнал аудита с пометкой via.
+func (s *jsonStore) step(fn func(j *journal, list *TaskList) (Operation, error), via string) (Operation, error) {
+	lock, err := lockFile(s.lockPath(), true)
+	if err != nil {
+		return Operation{}, fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return Operation{}, err
+	}
+
+	j, err := readJournal(s.journalPath())
+	if err != nil {
+		return Operation{}, fmt.Errorf("readJournal: %w", err)
+	}
+
+	before := list.clone()
+	prev := j.clone()
+	op, err := fn(&j, &list)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	err = s.commit(before, list, prev, j, auditEntries(diffTasks(before.all(), list.all()), timeNow(), via))
+	if err != nil {
+		return Operation{}, err
+	}
+
+	return op, nil
+}
+
+// Audit под разделяемой блокировкой считывает записи журнала аудита.
+func (s *jsonStore) Audit(since time.Time) ([]AuditEntry, error) {
+	lock, err := lockFile(s.lockPath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	entries, err := readAudit(s.auditPath(), since)
+	if err != nil {
+		return nil, fmt.Errorf("readAudit: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Get считывает файл и возвращает задачу по индексу.
+func (s *jsonStore) Get(index int) (models.Task, error) {
+	tasks, err := s.Load()
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	i := findTask(tasks, index)
+	if i == -1 {
+		return models.Task{}, ErrTaskNotFound
+	}
+
+	return tasks[i], nil
+}
+
+// Put добавляет или заменяет задачу в актуальной версии файла.
+func (s *jsonStore) Put(task models.Task) error {
+	return s.Modify(func(list *TaskList) error {
+		list.Tasks = putTask(list.Tasks, task)
+		return nil
+	})
+}
+
+// Delete удаляет задачу по индексу из актуальной версии файла.
+func (s *jsonStore) Delete(index int) error {
+	return s.Modify(func(list *TaskList) error {
+		tasks, err := deleteTask(list.Tasks, index)
+		if err != nil {
+			return err
+		}
+
+		list.Tasks = tasks
+		return nil
+	})
+}
+
+// List возвращает все задачи из файла.
+func (s *jsonStore) List() ([]models.Task, error) {
+	return s.Load()
+}
+
+// ListArchived под разделяемой блокировкой считывает задачи из файла архива.
+func (s *jsonStore) ListArchived() ([]models.Task, error) {
+	lock, err := lockFile(s.lockPath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("lockFile: %w", err)
+	}
+	defer lock.unlock()
+
+	archived, err := readArchive(s.archivePath())
+	if err != nil {
+		return nil, fmt.Errorf("readArchive: %w", err)
+	}
+
+	return archived, nil
+}
+
+// Watch отслеживает изменения файла задач.
+func (s *jsonStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	changes, err := watcher.Watch(ctx, s.path)
+	if err != nil {
+		return nil, fmt.Errorf("watcher.Watch: %w", err)
+	}
+
+	return changes, nil
+}
+
+// Where возвращает путь к файлу задач и источник, из которого он был выбран.
+func (s *jsonStore) Where() string {
+	return s.location.String()
+}
@@ -0,0 +1,559 @@
+package filemanager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/query"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/terminal"
+)
+
+var (
+	ErrInvalidSortKey error = errors.New("an invalid sort key was passed")
+	ErrInvalidColumn  error = errors.New("an invalid column was passed")
+)
+
+const (
+	// defaultSortKey используется, если пользователь не указал ключ сортировки.
+	defaultSortKey = "urgency"
+)
+
+// ListOptions описывает параметры вывода списка задач.
+type ListOptions struct {
+	// Sort — ключи сортировки через запятую с необязательным направлением (due,priority:asc).
+	// По умолчанию задачи упорядочиваются по убыванию срочности.
+	Sort string
+	// Tags — теги, которые должны быть у каждой выводимой задачи.
+	Tags []string
+	// Project — проект, задачи которого (включая подпроекты) выводятся.
+	Project string
+	// Statuses — названия или короткие названия статусов выводимых задач. nil выводит задачи в любом статусе,
+	// а пустой слайс не выводит ни одной задачи.
+	Statuses []string
+	// GroupBy — ключ группировки: status, project, tag или due (по неделям срока выполнения).
+	GroupBy string
+	// Columns — колонки списка в порядке вывода. По умолчанию выводятся все колонки.
+	Columns []string
+	// Filter — выражение фильтра (см. пакет query), например: status:open priority>=high +urgent.
+	Filter string
+	// All добавляет в список задачи из архива (см. WithArchived).
+	All bool
+	// Format — формат вывода: text (по умолчанию), json, jsonl, csv, tsv, yaml или markdown.
+	Format string
+}
+
+// queryEnv возвращает окружение для разбора фильтров: статусы из настроек, приоритеты и срочность задач.
+func queryEnv() query.Env {
+	return query.Env{
+		Now:      time.Now(),
+		Statuses: parseStatusFilter,
+		Priority: parsePriority,
+		Urgency: func(task models.Task) float64 {
+			return Urgency(task, timeNow())
+		},
+	}
+}
+
+// resolveStatuses приводит статусы из ListOptions к названиям из настроек.
+func resolveStatuses(statuses []string) ([]models.TaskStatus, error) {
+	if statuses == nil {
+		return nil, nil
+	}
+
+	result := make([]models.TaskStatus, 0, len(statuses))
+	for _, element := range statuses {
+		status, err := parseStatus(element)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// filterTasks оставляет задачи, подходящие под статусы, теги, проект и фильтр из ListOptions.
+func filterTasks(tasks []models.Task, statuses []models.TaskStatus, filter *query.Query, opts ListOptions) []models.Task {
+	var result []models.Task
+
+	for _, task := range tasks {
+		if !filter.Match(task) {
+			continue
+		}
+		if statuses != nil && !slices.Contains(statuses, task.Status) {
+			continue
+		}
+		if !hasTags(task, opts.Tags) {
+			continue
+		}
+		if opts.Project != "" && !inProject(task, opts.Project) {
+			continue
+		}
+		result = append(result, task)
+	}
+
+	return result
+}
+
+// sortKey — ключ сортировки списка задач.
+type sortKey struct {
+	// compare сравнивает задачи по возрастанию.
+	compare func(a, b models.Task) int
+	// descending — направление сортировки, если пользователь его не указал.
+	descending bool
+	// missing сообщает, что у задачи нет значения ключа. Такие задачи выводятся в конце при любом направлении.
+	missing func(task models.Task) bool
+}
+
+// timeKey возвращает ключ сортировки по временной метке задачи.
+func timeKey(get func(task models.Task) time.Time) sortKey {
+	return sortKey{
+		compare: func(a, b models.Task) int { return get(a).Compare(get(b)) },
+		missing: func(task models.Task) bool { return get(task).IsZero() },
+	}
+}
+
+// sortKeys сопоставляет ключам сортировки способы сравнения задач.
+// Приоритет и срочность по умолчанию сортируются по убыванию, остальные ключи — по возрастанию.
+var sortKeys = map[string]sortKey{
+	"created":   timeKey(func(task models.Task) time.Time { return task.CreatedAt }),
+	"updated":   timeKey(func(task models.Task) time.Time { return task.UpdatedAt }),
+	"started":   timeKey(func(task models.Task) time.Time { return task.StartedAt }),
+	"completed": timeKey(func(task models.Task) time.Time { return task.CompletedAt }),
+	"due":       timeKey(func(task models.Task) time.Time { return task.Due }),
+	"archived":  timeKey(func(task models.Task) time.Time { return task.ArchivedAt }),
+	"deleted":   timeKey(func(task models.Task) time.Time { return task.DeletedAt }),
+	"index":     {compare: func(a, b models.Task) int { return cmp.Compare(a.Index, b.Index) }},
+	"name": {compare: func(a, b models.Task) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}},
+	// Статусы упорядочиваются так же, как в настройках.
+	"status": {compare: func(a, b models.Task) int {
+		return cmp.Compare(statusOrder(a.Status), statusOrder(b.Status))
+	}},
+	"priority": {compare: func(a, b models.Task) int { return cmp.Compare(a.Priority, b.Priority) }, descending: true},
+	"urgency": {compare: func(a, b models.Task) int {
+		at := timeNow()
+		return cmp.Compare(Urgency(a, at), Urgency(b, at))
+	}, descending: true},
+}
+
+// sortField — ключ сортировки с выбранным направлением.
+type sortField struct {
+	key        sortKey
+	descending bool
+}
+
+// parseSort разбирает ключи сортировки через запятую. Направление указывается суффиксом :asc или :desc
+// либо префиксом + (по возрастанию) или - (по убыванию): due,priority:asc или -created,name.
+func parseSort(spec string) ([]sortField, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = defaultSortKey
+	}
+
+	var fields []sortField
+	for _, element := range strings.Split(spec, ",") {
+		element = strings.ToLower(strings.TrimSpace(element))
+		name, direction, _ := strings.Cut(element, ":")
+		switch {
+		case strings.HasPrefix(name, "-"):
+			name, direction = name[1:], "desc"
+		case strings.HasPrefix(name, "+"):
+			name, direction = name[1:], "asc"
+		}
+
+		key, ok := sortKeys[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSortKey, element)
+		}
+
+		field := sortField{key: key, descending: key.descending}
+		switch direction {
+		case "":
+		case "asc":
+			field.descending = false
+		case "desc":
+			field.descending = true
+		default:
+			return nil, fmt.Errorf("%w: %s (expected asc or desc)", ErrInvalidSortKey, element)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+const (
+	colorRed   = "\x1b[31m"
+	colorReset = "\x1b[0m"
+	// treeIndent — отступ подзадачи относительно родителя.
+	treeIndent = "    "
+)
+
+// sortTasks сортирует задачи по ключам из ListOptions. При равенстве всех ключей задачи упорядочиваются по номеру.
+func sortTasks(tasks []models.Task, spec string) error {
+	fields, err := parseSort(spec)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(tasks, func(a, b models.Task) int {
+		for _, field := range fields {
+			if field.key.missing != nil {
+				aMissing, bMissing := field.key.missing(a), field.key.missing(b)
+				switch {
+				case aMissing && bMissing:
+					continue
+				case aMissing:
+					return 1
+				case bMissing:
+					return -1
+				}
+			}
+
+			c := field.key.compare(a, b)
+			if field.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	return nil
+}
+
+// column — колонка списка задач. value возвращает фрагмент строки вида "Name: value"
+// или пустую строку, если у задачи нет значения. field возвращает значение колонки для машиночитаемых
+// форматов вывода (см. writeFormatted) или nil, если у задачи нет значения.
+type column struct {
+	name  string
+	value func(all []models.Task, task models.Task, at time.Time) string
+	field func(all []models.Task, task models.Task, at time.Time) any
+}
+
+// columns перечисляет колонки списка задач в порядке вывода по умолчанию.
+var columns = []column{
+	{"index", func(_ []models.Task, task models.Task, _ time.Time) string {
+		return fmt.Sprintf("Index: %d", task.Index)
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return task.Index }},
+	{"id", func(_ []models.Task, task models.Task, _ time.Time) string {
+		return fmt.Sprintf("ID: %s", ShortID(task))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return task.ID }},
+	{"name", func(_ []models.Task, task models.Task, _ time.Time) string {
+		return fmt.Sprintf("Name: %s", task.Name)
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return task.Name }},
+	{"status", func(_ []models.Task, task models.Task, _ time.Time) string {
+		return fmt.Sprintf("Status: %s", statusName(task.Status))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return string(task.Status) }},
+	{"priority", func(_ []models.Task, task models.Task, _ time.Time) string {
+		return fmt.Sprintf("Priority: %s", priorityName(task.Priority))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any {
+		return strings.ToLower(priorityName(task.Priority))
+	}},
+	{"urgency", func(_ []models.Task, task models.Task, at time.Time) string {
+		return fmt.Sprintf("Urgency: %.2f", Urgency(task, at))
+	}, func(_ []models.Task, task models.Task, at time.Time) any {
+		return math.Round(Urgency(task, at)*100) / 100
+	}},
+	{"created", func(_ []models.Task, task models.Task, at time.Time) string {
+		return fmt.Sprintf("Created: %s", relativeTime(task.CreatedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return timeField(task.CreatedAt) }},
+	{"updated", func(_ []models.Task, task models.Task, at time.Time) string {
+		return fmt.Sprintf("Updated: %s", relativeTime(task.UpdatedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return timeField(task.UpdatedAt) }},
+	{"completed", func(_ []models.Task, task models.Task, at time.Time) string {
+		if !isDone(task) || task.CompletedAt.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("Completed: %s", relativeTime(task.CompletedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any {
+		if !isDone(task) {
+			return nil
+		}
+		return timeField(task.CompletedAt)
+	}},
+	{"started", func(_ []models.Task, task models.Task, at time.Time) string {
+		if !isStarted(task.Status) || task.StartedAt.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("Started: %s", relativeTime(task.StartedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any {
+		if !isStarted(task.Status) {
+			return nil
+		}
+		return timeField(task.StartedAt)
+	}},
+	{"blocked", func(all []models.Task, task models.Task, _ time.Time) string {
+		if blocking := blockers(all, task); len(blocking) > 0 && !isDone(task) {
+			return fmt.Sprintf("BLOCKED by %s", blockerIndexes(blocking))
+		}
+		return ""
+	}, func(all []models.Task, task models.Task, _ time.Time) any {
+		indexes := []int{}
+		if !isDone(task) {
+			for _, blocking := range blockers(all, task) {
+				indexes = append(indexes, blocking.Index)
+			}
+		}
+		return indexes
+	}},
+	{"subtasks", func(all []models.Task, task models.Task, _ time.Time) string {
+		if done, total := progress(all, task); total > 0 {
+			return fmt.Sprintf("Subtasks: %d/%d done", done, total)
+		}
+		return ""
+	}, func(all []models.Task, task models.Task, _ time.Time) any {
+		if done, total := progress(all, task); total > 0 {
+			return fmt.Sprintf("%d/%d", done, total)
+		}
+		return nil
+	}},
+	{"project", func(_ []models.Task, task models.Task, _ time.Time) string {
+		if task.Project == "" {
+			return ""
+		}
+		return fmt.Sprintf("Project: %s", task.Project)
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return stringField(task.Project) }},
+	{"tags", func(_ []models.Task, task models.Task, _ time.Time) string {
+		if len(task.Tags) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("Tags: %s", strings.Join(task.Tags, ","))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return append([]string{}, task.Tags...) }},
+	{"recur", func(all []models.Task, task models.Task, _ time.Time) string {
+		if task.Recur == "" {
+			return ""
+		}
+		if done := completedOccurrences(all, task); done > 0 {
+			return fmt.Sprintf("Recur: %s (%d done)", task.Recur, done)
+		}
+		return fmt.Sprintf("Recur: %s", task.Recur)
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return stringField(task.Recur) }},
+	{"due", func(_ []models.Task, task models.Task, at time.Time) string {
+		if task.Due.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("Due: %s (%s)", dates.Format(task.Due), relativeTime(task.Due, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return timeField(task.Due) }},
+	{"archived", func(_ []models.Task, task models.Task, at time.Time) string {
+		if task.ArchivedAt.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("Archived: %s", relativeTime(task.ArchivedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return timeField(task.ArchivedAt) }},
+	{"deleted", func(_ []models.Task, task models.Task, at time.Time) string {
+		if task.DeletedAt.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("Deleted: %s", relativeTime(task.DeletedAt, at))
+	}, func(_ []models.Task, task models.Task, _ time.Time) any { return timeField(task.DeletedAt) }},
+}
+
+// columnNames возвращает названия всех колонок списка задач.
+func columnNames() []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+
+	return names
+}
+
+// selectColumns возвращает колонки по названиям в указанном порядке. Без названий возвращаются все колонки.
+func selectColumns(names []string) ([]column, error) {
+	if len(names) == 0 {
+		return columns, nil
+	}
+
+	selected := make([]column, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(columns, func(c column) bool { return strings.EqualFold(c.name, strings.TrimSpace(name)) })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s (available: %s)", ErrInvalidColumn, name, strings.Join(columnNames(), ", "))
+		}
+		selected = append(selected, columns[i])
+	}
+
+	return selected, nil
+}
+
+// writeTask записывает в b строку списка с колонками задачи task на глубине depth дерева подзадач.
+// Просроченные задачи выделяются цветом и пометкой OVERDUE независимо от набора колонок.
+func writeTask(b *strings.Builder, all []models.Task, task models.Task, depth int, cols []column, at time.Time, colored bool) {
+	b.WriteString(strings.Repeat(treeIndent, depth))
+
+	overdue := isOverdue(task, at)
+	if overdue && colored {
+		b.WriteString(colorRed)
+	}
+
+	var values []string
+	for _, c := range cols {
+		if value := c.value(all, task, at); value != "" {
+			values = append(values, value)
+		}
+	}
+	b.WriteString(strings.Join(values, "\t"))
+
+	if overdue {
+		b.WriteString("\tOVERDUE")
+		if colored {
+			b.WriteString(colorReset)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// writeTree записывает в b задачи, разложенные в дерево подзадач.
+func writeTree(b *strings.Builder, all, tasks []models.Task, cols []column, at time.Time, colored bool) {
+	for _, item := range buildTree(tasks) {
+		writeTask(b, all, item.task, item.depth, cols, at, colored)
+	}
+}
+
+// printTasks реализует вывод в терминал список задач с преобразованием их статуса и временных меток в читаемый вид.
+// Подзадачи выводятся с отступом под своими родителями, для родителей выводится прогресс по всем подзадачам из all.
+// Если в opts указан ключ группировки, задачи выводятся по разделам с заголовком и количеством задач.
+// Если в opts указан машиночитаемый формат, задачи выводятся в нем без постраничного просмотра (см. writeFormatted).
+func printTasks(all, tasks []models.Task, opts ListOptions) error {
+	format, err := parseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := resolveStatuses(opts.Statuses)
+	if err != nil {
+		return err
+	}
+
+	filter, err := query.Parse(opts.Filter, queryEnv())
+	if err != nil {
+		return err
+	}
+
+	tasks = filterTasks(tasks, statuses, filter, opts)
+	err = sortTasks(tasks, opts.Sort)
+	if err != nil {
+		return err
+	}
+
+	groups, err := groupTasks(tasks, opts.GroupBy)
+	if err != nil {
+		return err
+	}
+
+	cols, err := selectColumns(opts.Columns)
+	if err != nil {
+		return err
+	}
+
+	at := timeNow()
+	if format != FormatText {
+		return writeFormatted(os.Stdout, format, recordHeader(cols, opts.GroupBy != ""), taskRecords(all, tasks, groups, cols, at))
+	}
+
+	colored := terminal.IsTerminal(os.Stdout)
+	var resBuild strings.Builder
+	if opts.GroupBy == "" {
+		writeTree(&resBuild, all, tasks, cols, at, colored)
+	}
+	for i, group := range groups {
+		if i > 0 {
+			resBuild.WriteString("\n")
+		}
+		resBuild.WriteString(fmt.Sprintf("== %s (%d) ==\n", group.title, len(group.tasks)))
+		writeTree(&resBuild, all, group.tasks, cols, at, colored)
+	}
+
+	return page(resBuild.String())
+}
+
+// page выводит текст в терминал через less. Если вывод не подключен к терминалу (например, перенаправлен
+// в файл или другую программу), текст выводится как есть.
+func page(text string) error {
+	if !terminal.IsTerminal(os.Stdout) {
+		_, err := io.WriteString(os.Stdout, text)
+		return err
+	}
+
+	// Флаг -R позволяет less выводить цветовые escape-последовательности.
+	cmd := exec.Command("less", "-R")
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// isOverdue сообщает, что срок выполнения незавершенной задачи уже прошел.
+func isOverdue(task models.Task, at time.Time) bool {
+	return !isDone(task) && !task.Due.IsZero() && task.Due.Before(at)
+}
+
+// filterDue возвращает незавершенные задачи со сроком выполнения, для которого match возвращает true.
+func filterDue(tasks []models.Task, match func(due time.Time) bool) []models.Task {
+	var result []models.Task
+
+	for _, task := range tasks {
+		if !isDone(task) && !task.Due.IsZero() && match(task.Due) {
+			result = append(result, task)
+		}
+	}
+
+	return result
+}
+
+// ListTasks передает в функцию для вывода в терминал список задач пользователя, подходящих под статусы,
+// теги и проект из opts. Без фильтров выводятся все задачи.
+func ListTasks(tasks []models.Task, opts ListOptions) error {
+	err := printTasks(tasks, tasks, opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
+
+// OverdueTasks передает в функцию для вывода в терминал список незавершенных задач с прошедшим сроком выполнения.
+func OverdueTasks(tasks []models.Task, opts ListOptions) error {
+	at := timeNow()
+	err := printTasks(tasks, filterDue(tasks, func(due time.Time) bool { return due.Before(at) }), opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
+
+// DueTodayTasks передает в функцию для вывода в терминал список незавершенных задач, срок выполнения
+// которых наступает сегодня.
+func DueTodayTasks(tasks []models.Task, opts ListOptions) error {
+	today := time.Now()
+	err := printTasks(tasks, filterDue(tasks, func(due time.Time) bool { return dates.SameDay(today, due) }), opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
+
+// DueThisWeekTasks передает в функцию для вывода в терминал список незавершенных задач, срок выполнения
+// которых наступает до конца текущей недели, включая просроченные.
+func DueThisWeekTasks(tasks []models.Task, opts ListOptions) error {
+	endOfWeek := dates.EndOfWeek(time.Now())
+	err := printTasks(tasks, filterDue(tasks, func(due time.Time) bool { return !due.After(endOfWeek) }), opts)
+	if err != nil {
+		return fmt.Errorf("printTasks: %w", err)
+	}
+	return nil
+}
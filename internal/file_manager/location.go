@@ -0,0 +1,118 @@
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	EnvTasksFile    = "TASKTRACKER_FILE"
+	ProjectFileName = ".tasks.json"
+	defaultFileName = "tasks.json"
+	appDirName      = "tasktracker"
+)
+
+// Источники, из которых был выбран путь к файлу задач.
+const (
+	SourceFlag    = "--file flag"
+	SourceEnv     = EnvTasksFile + " environment variable"
+	SourceProject = "project file"
+	SourceXDG     = "XDG data directory"
+)
+
+// Location описывает выбранный файл задач и источник, из которого он был выбран.
+type Location struct {
+	Path   string
+	Source string
+}
+
+// String возвращает описание расположения файла для команды where.
+func (l Location) String() string {
+	return fmt.Sprintf("%s (%s)", l.Path, l.Source)
+}
+
+// ResolveLocation определяет, с каким файлом задач работать. Порядок выбора:
+// флаг --file, переменная окружения TASKTRACKER_FILE, ближайший файл .tasks.json
+// в текущей или родительских директориях и, наконец, $XDG_DATA_HOME/tasktracker/tasks.json.
+func ResolveLocation(flagPath string) (Location, error) {
+	if flagPath != "" {
+		return absLocation(flagPath, SourceFlag)
+	}
+
+	if envPath := os.Getenv(EnvTasksFile); envPath != "" {
+		return absLocation(envPath, SourceEnv)
+	}
+
+	projectPath, err := findProjectFile()
+	if err != nil {
+		return Location{}, fmt.Errorf("findProjectFile: %w", err)
+	}
+	if projectPath != "" {
+		return Location{
+			Path:   projectPath,
+			Source: SourceProject,
+		}, nil
+	}
+
+	dataDir, err := dataHome()
+	if err != nil {
+		return Location{}, fmt.Errorf("dataHome: %w", err)
+	}
+
+	return Location{
+		Path:   filepath.Join(dataDir, appDirName, defaultFileName),
+		Source: SourceXDG,
+	}, nil
+}
+
+// absLocation приводит путь к абсолютному виду.
+func absLocation(path, source string) (Location, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Location{}, fmt.Errorf("filepath.Abs: %w", err)
+	}
+
+	return Location{
+		Path:   absPath,
+		Source: source,
+	}, nil
+}
+
+// findProjectFile ищет файл .tasks.json, поднимаясь от текущей директории к корню.
+// Если файл не найден, возвращается пустая строка.
+func findProjectFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("os.Getwd: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// dataHome возвращает $XDG_DATA_HOME или ~/.local/share, если переменная не задана.
+// Относительный путь в переменной игнорируется, как требует спецификация XDG Base Directory.
+func dataHome() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("os.UserHomeDir: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share"), nil
+}
@@ -0,0 +1,37 @@
+package filemanager
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDataHome(t *testing.T) {
+	home := t.TempDir()
+	data := filepath.Join(t.TempDir(), "data")
+
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{"absolute", data, data},
+		{"relative", filepath.Join("relative", "data"), filepath.Join(home, ".local", "share")},
+		{"not set", "", filepath.Join(home, ".local", "share")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("USERPROFILE", home)
+			t.Setenv("XDG_DATA_HOME", tt.env)
+
+			got, err := dataHome()
+			if err != nil {
+				t.Fatalf("dataHome: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("dataHome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
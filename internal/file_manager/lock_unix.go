@@ -0,0 +1,56 @@
+//go:build !windows
+
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileLock удерживает advisory-блокировку (flock) на lock-файле рядом с файлом задач.
+// Блокировка берется на отдельный файл, потому что сам файл задач заменяется через rename
+// и блокировка на него потерялась бы вместе со старым inode.
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile открывает lock-файл и ждет получения блокировки: разделяемой для чтения
+// или эксклюзивной для изменения данных.
+func lockFile(path string, exclusive bool) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), how)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("syscall.Flock: %w", err)
+	}
+
+	return &fileLock{
+		file: file,
+	}, nil
+}
+
+// unlock снимает блокировку и закрывает lock-файл.
+func (l *fileLock) unlock() error {
+	err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		l.file.Close()
+		return fmt.Errorf("syscall.Flock: %w", err)
+	}
+
+	return l.file.Close()
+}
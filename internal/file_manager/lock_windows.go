@@ -0,0 +1,17 @@
+//go:build windows
+
+package filemanager
+
+// fileLock на Windows ничего не блокирует: стандартная библиотека не предоставляет flock,
+// поэтому межпроцессная блокировка поддерживается только в Unix-системах.
+type fileLock struct{}
+
+// lockFile возвращает пустую блокировку.
+func lockFile(path string, exclusive bool) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+// unlock ничего не делает.
+func (l *fileLock) unlock() error {
+	return nil
+}
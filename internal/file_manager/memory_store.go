@@ -0,0 +1,211 @@
+package filemanager
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// memoryStore хранит задачи в памяти процесса. Используется в тестах и как временное хранилище.
+type memoryStore struct {
+	mu        sync.Mutex
+	tasks     []models.Task
+	archived  []models.Task
+	nextIndex int
+	journal   journal
+	audit     []AuditEntry
+}
+
+// NewMemoryStore создает хранилище в памяти с начальным набором задач.
+func NewMemoryStore(tasks ...models.Task) *memoryStore {
+	return &memoryStore{
+		tasks: cloneTasks(tasks),
+	}
+}
+
+// cloneTasks возвращает копию задач вместе с их тегами, зависимостями и заметками, чтобы изменения копии
+// на месте (например, slices.DeleteFunc) не затрагивали хранимые задачи и снимок для журнала операций.
+func cloneTasks(tasks []models.Task) []models.Task {
+	if tasks == nil {
+		return nil
+	}
+
+	cloned := make([]models.Task, len(tasks))
+	for i, task := range tasks {
+		cloned[i] = cloneTask(task)
+	}
+
+	return cloned
+}
+
+// cloneTask возвращает копию задачи, которая не делит с исходной задачей теги, зависимости и заметки.
+func cloneTask(task models.Task) models.Task {
+	task.Tags = slices.Clone(task.Tags)
+	task.DependsOn = slices.Clone(task.DependsOn)
+	task.Notes = slices.Clone(task.Notes)
+
+	return task
+}
+
+// Load возвращает копию хранимых задач.
+func (s *memoryStore) Load() ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return cloneTasks(s.tasks), nil
+}
+
+// Save заменяет хранимые задачи копией переданного списка.
+func (s *memoryStore) Save(tasks []models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(s.tasks, tasks)
+	s.tasks = cloneTasks(tasks)
+	return nil
+}
+
+// Modify передает в fn копию хранимых задач и сохраняет результат, если fn не вернула ошибку.
+func (s *memoryStore) Modify(fn func(list *TaskList) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.list()
+	err := fn(&list)
+	if err != nil {
+		return err
+	}
+
+	list.normalize()
+	s.record(slices.Concat(s.tasks, s.archived), list.all())
+	s.tasks = list.Tasks
+	s.archived = list.Archived
+	s.nextIndex = list.NextIndex
+	return nil
+}
+
+// Get возвращает задачу по индексу.
+func (s *memoryStore) Get(index int) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := findTask(s.tasks, index)
+	if i == -1 {
+		return models.Task{}, ErrTaskNotFound
+	}
+
+	return cloneTask(s.tasks[i]), nil
+}
+
+// Put добавляет задачу или заменяет существующую задачу с тем же индексом.
+func (s *memoryStore) Put(task models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := putTask(cloneTasks(s.tasks), cloneTask(task))
+	s.record(s.tasks, tasks)
+	s.tasks = tasks
+	return nil
+}
+
+// Delete удаляет задачу по индексу.
+func (s *memoryStore) Delete(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, err := deleteTask(cloneTasks(s.tasks), index)
+	if err != nil {
+		return err
+	}
+
+	s.record(s.tasks, tasks)
+	s.tasks = tasks
+	return nil
+}
+
+// List возвращает копию хранимых задач.
+func (s *memoryStore) List() ([]models.Task, error) {
+	return s.Load()
+}
+
+// ListArchived возвращает копию задач из архива и корзины.
+func (s *memoryStore) ListArchived() ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return cloneTasks(s.archived), nil
+}
+
+// list возвращает копию хранимых задач вместе с архивом и счетчиком номеров.
+func (s *memoryStore) list() TaskList {
+	return TaskList{
+		Tasks:     cloneTasks(s.tasks),
+		Archived:  cloneTasks(s.archived),
+		NextIndex: s.nextIndex,
+	}
+}
+
+// Watch возвращает канал, который не получает сигналов: хранилище в памяти не может быть изменено
+// другим процессом. Канал закрывается после отмены ctx.
+func (s *memoryStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	changes := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(changes)
+	}()
+
+	return changes, nil
+}
+
+// Where сообщает, что задачи хранятся только в памяти.
+func (s *memoryStore) Where() string {
+	return "in-memory store"
+}
+
+// record добавляет изменение задач в журнал операций и журнал аудита в памяти.
+func (s *memoryStore) record(before, after []models.Task) {
+	if changes := diffTasks(before, after); len(changes) > 0 {
+		at := timeNow()
+		s.journal.record(Operation{At: at, Changes: changes})
+		s.audit = append(s.audit, auditEntries(changes, at, "")...)
+	}
+}
+
+// Undo отменяет последнюю операцию из журнала в памяти.
+func (s *memoryStore) Undo() (Operation, error) {
+	return s.step((*journal).undo, "undo")
+}
+
+// Redo повторно применяет последнюю отмененную операцию из журнала в памяти.
+func (s *memoryStore) Redo() (Operation, error) {
+	return s.step((*journal).redo, "redo")
+}
+
+// step применяет к хранимым задачам шаг по журналу операций и добавляет изменение в журнал аудита с пометкой via.
+func (s *memoryStore) step(fn func(j *journal, list *TaskList) (Operation, error), via string) (Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.list()
+	op, err := fn(&s.journal, &list)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	s.audit = append(s.audit, auditEntries(diffTasks(slices.Concat(s.tasks, s.archived), list.all()), timeNow(), via)...)
+	s.tasks = list.Tasks
+	s.archived = list.Archived
+	s.nextIndex = list.NextIndex
+	return op, nil
+}
+
+// Audit возвращает записи журнала аудита в памяти.
+func (s *memoryStore) Audit(since time.Time) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filterAudit(s.audit, since), nil
+}
@@ -0,0 +1,109 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var errIncorrectPriority error = errors.New("an incorrect task priority was passed")
+
+// priorityNames сопоставляет допустимые варианты ввода приоритета с его значением.
+var priorityNames = map[string]models.Priority{
+	"none": models.PriorityNone, "n": models.PriorityNone, "0": models.PriorityNone,
+	"low": models.PriorityLow, "l": models.PriorityLow, "1": models.PriorityLow,
+	"medium": models.PriorityMedium, "m": models.PriorityMedium, "2": models.PriorityMedium,
+	"high": models.PriorityHigh, "h": models.PriorityHigh, "3": models.PriorityHigh,
+	"critical": models.PriorityCritical, "c": models.PriorityCritical, "4": models.PriorityCritical,
+}
+
+// Коэффициенты оценки срочности задачи. Значения подобраны по аналогии с Taskwarrior.
+const (
+	urgencyDueCoefficient        = 12.0
+	urgencyAgeCoefficient        = 2.0
+	urgencyInProgressCoefficient = 4.0
+	// urgencyMaxAge — возраст задачи, после которого вклад возраста в срочность перестает расти.
+	urgencyMaxAge = 365 * 24 * time.Hour
+)
+
+var urgencyPriority = map[models.Priority]float64{
+	models.PriorityNone:     0,
+	models.PriorityLow:      1.8,
+	models.PriorityMedium:   3.9,
+	models.PriorityHigh:     6.0,
+	models.PriorityCritical: 8.0,
+}
+
+// parsePriority преобразует введенный пользователем приоритет: название (none, low, medium, high, critical),
+// его первую букву (H, M, L) или число от 0 до 4.
+func parsePriority(element string) (models.Priority, error) {
+	priority, ok := priorityNames[strings.ToLower(strings.TrimSpace(element))]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", errIncorrectPriority, element)
+	}
+
+	return priority, nil
+}
+
+// priorityName возвращает читаемое название приоритета.
+func priorityName(priority models.Priority) string {
+	switch priority {
+	case models.PriorityNone:
+		return "None"
+	case models.PriorityLow:
+		return "Low"
+	case models.PriorityMedium:
+		return "Medium"
+	case models.PriorityHigh:
+		return "High"
+	case models.PriorityCritical:
+		return "Critical"
+	default:
+		return "Incorrect priority"
+	}
+}
+
+// Urgency вычисляет оценку срочности задачи из ее приоритета, срока выполнения, возраста и статуса.
+// Выполненные задачи имеют нулевую срочность.
+func Urgency(task models.Task, at time.Time) float64 {
+	if isDone(task) {
+		return 0
+	}
+
+	urgency := urgencyPriority[task.Priority]
+	urgency += urgencyDueCoefficient * dueFactor(task.Due, at)
+
+	if !task.CreatedAt.IsZero() {
+		age := at.Sub(task.CreatedAt)
+		urgency += urgencyAgeCoefficient * math.Min(float64(age)/float64(urgencyMaxAge), 1)
+	}
+
+	if isStarted(task.Status) {
+		urgency += urgencyInProgressCoefficient
+	}
+
+	return math.Round(urgency*100) / 100
+}
+
+// dueFactor возвращает вклад срока выполнения от 0.2 до 1: задачи, просроченные на неделю и больше, получают 1,
+// задачи со сроком через две недели и позже — 0.2, между этими границами значение меняется линейно.
+// Задачи без срока получают 0.
+func dueFactor(due, at time.Time) float64 {
+	if due.IsZero() {
+		return 0
+	}
+
+	days := at.Sub(due).Hours() / 24
+	switch {
+	case days >= 7:
+		return 1
+	case days <= -14:
+		return 0.2
+	}
+
+	return ((days+14)*0.8)/21 + 0.2
+}
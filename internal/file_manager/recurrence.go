@@ -0,0 +1,266 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var (
+	ErrRecurrenceNotExists error = errors.New("recurrence rule is missing from the passed arguments")
+	errIncorrectRecurrence error = errors.New("an incorrect recurrence rule was passed")
+)
+
+// Правила повторения задач. В файле правило хранится строкой: daily, weekly:mon,fri, monthly:15, after:3d.
+const (
+	recurDaily   = "daily"
+	recurWeekly  = "weekly"
+	recurMonthly = "monthly"
+	recurAfter   = "after"
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseRecurrence проверяет правило повторения, введенное пользователем, и приводит его к виду, в котором
+// оно хранится в файле. Значение none означает отсутствие повторения и возвращается пустой строкой.
+func parseRecurrence(element string) (string, error) {
+	element = strings.ToLower(trimQuotes(strings.TrimSpace(element)))
+	if element == "none" {
+		return "", nil
+	}
+
+	kind, value, hasValue := strings.Cut(element, ":")
+	switch kind {
+	case recurDaily:
+		if hasValue {
+			break
+		}
+		return recurDaily, nil
+
+	case recurWeekly:
+		if !hasValue {
+			return recurWeekly, nil
+		}
+
+		var days []time.Weekday
+		for _, name := range strings.Split(value, ",") {
+			day, ok := dates.ParseWeekday(name)
+			if !ok {
+				return "", fmt.Errorf("%w: unknown weekday %q", errIncorrectRecurrence, name)
+			}
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+		slices.Sort(days)
+
+		names := make([]string, 0, len(days))
+		for _, day := range days {
+			names = append(names, weekdayNames[day])
+		}
+		return recurWeekly + ":" + strings.Join(names, ","), nil
+
+	case recurMonthly:
+		if !hasValue {
+			return recurMonthly, nil
+		}
+
+		day, err := strconv.Atoi(value)
+		if err != nil || day < 1 || day > 31 {
+			return "", fmt.Errorf("%w: day of month must be from 1 to 31", errIncorrectRecurrence)
+		}
+		return recurMonthly + ":" + strconv.Itoa(day), nil
+
+	case recurAfter:
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days < 1 {
+			return "", fmt.Errorf("%w: expected number of days, for example after:3d", errIncorrectRecurrence)
+		}
+		return recurAfter + ":" + strconv.Itoa(days) + "d", nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errIncorrectRecurrence, element)
+}
+
+// nextDue вычисляет срок следующего вхождения повторяющейся задачи, выполненной в момент completed.
+// Правила по расписанию отсчитываются от срока выполненного вхождения (или от момента выполнения, если срока не было)
+// и пропускают уже прошедшие даты. Правило after отсчитывает дни от момента выполнения.
+func nextDue(task models.Task, completed time.Time) time.Time {
+	completed = completed.Local()
+	base := recurrenceBase(task, completed)
+
+	kind, value, _ := strings.Cut(task.Recur, ":")
+	if kind == recurAfter {
+		days, _ := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		next := completed.AddDate(0, 0, days)
+		return time.Date(next.Year(), next.Month(), next.Day(), base.Hour(), base.Minute(), base.Second(), 0, base.Location())
+	}
+
+	next := base
+	for {
+		next = nextOccurrence(kind, value, next, base)
+		if !next.Before(completed) {
+			return next
+		}
+	}
+}
+
+// recurrenceBase возвращает дату, от которой отсчитывается следующее вхождение: срок задачи или конец дня
+// выполнения, если срока не было.
+func recurrenceBase(task models.Task, completed time.Time) time.Time {
+	if task.Due.IsZero() {
+		return dates.EndOfDay(completed.Local())
+	}
+
+	return task.Due.Local()
+}
+
+// anchorRecurrence закрепляет в правиле monthly без числа день месяца из base. Следующие вхождения получают
+// правило с числом и не смещаются после коротких месяцев: за сроком 31 января следует 28 февраля, а за ним — 31 марта.
+func anchorRecurrence(rule string, base time.Time) string {
+	if rule != recurMonthly {
+		return rule
+	}
+
+	return recurMonthly + ":" + strconv.Itoa(base.Day())
+}
+
+// nextOccurrence возвращает дату, следующую за from по правилу kind со значением value.
+// Из base берутся день недели и день месяца, если правило их не указывает.
+func nextOccurrence(kind, value string, from, base time.Time) time.Time {
+	switch kind {
+	case recurWeekly:
+		days := []time.Weekday{base.Weekday()}
+		if value != "" {
+			days = days[:0]
+			for _, name := range strings.Split(value, ",") {
+				day, _ := dates.ParseWeekday(name)
+				days = append(days, day)
+			}
+		}
+
+		for i := 1; ; i++ {
+			next := from.AddDate(0, 0, i)
+			if slices.Contains(days, next.Weekday()) {
+				return next
+			}
+		}
+
+	case recurMonthly:
+		day := base.Day()
+		if value != "" {
+			day, _ = strconv.Atoi(value)
+		}
+
+		next := monthDay(from.Year(), from.Month(), day, from)
+		if !next.After(from) {
+			next = monthDay(from.Year(), from.Month()+1, day, from)
+		}
+		return next
+	}
+
+	return from.AddDate(0, 0, 1)
+}
+
+// monthDay возвращает указанный день месяца со временем из clock. Если в месяце меньше дней,
+// возвращается последний день месяца.
+func monthDay(year int, month time.Month, day int, clock time.Time) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, clock.Location()).Day()
+	return time.Date(year, month, min(day, last), clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+}
+
+// recur создает следующее вхождение повторяющейся задачи, если задача с позиции i выполнена.
+// Выполненное вхождение остается в списке как история серии, а новое получает следующий срок.
+// Если в серии уже есть невыполненное вхождение, новое не создается.
+func recur(list *TaskList, i int, at time.Time) (models.Task, bool) {
+	task := &list.Tasks[i]
+	if task.Recur == "" || !isDone(*task) {
+		return models.Task{}, false
+	}
+
+	if task.SeriesID == "" {
+		task.SeriesID = task.ID
+	}
+	pending := slices.ContainsFunc(list.Tasks, func(t models.Task) bool {
+		return t.SeriesID == task.SeriesID && !isDone(t)
+	})
+	if pending {
+		return models.Task{}, false
+	}
+
+	next := models.Task{
+		Name:      task.Name,
+		Status:    initialStatus(),
+		CreatedAt: at,
+		UpdatedAt: at,
+		Due:       nextDue(*task, task.CompletedAt),
+		Priority:  task.Priority,
+		Tags:      slices.Clone(task.Tags),
+		Project:   task.Project,
+		ParentID:  task.ParentID,
+		Recur:     anchorRecurrence(task.Recur, recurrenceBase(*task, task.CompletedAt)),
+		SeriesID:  task.SeriesID,
+	}
+
+	return list.NewTask(next), true
+}
+
+// completedOccurrences возвращает количество выполненных вхождений серии, к которой относится задача.
+func completedOccurrences(tasks []models.Task, task models.Task) int {
+	if task.SeriesID == "" {
+		return 0
+	}
+
+	count := 0
+	for _, t := range tasks {
+		if t.SeriesID == task.SeriesID && isDone(t) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// SetRecurrence реализует установку правила повторения задачи по номеру или префиксу ID задачи.
+// Значение none отключает повторение. После чего возвращает сообщение о результате действия или ошибку.
+func SetRecurrence(store Store, tasks *[]models.Task, elements []string) (string, error) {
+	if elements[0] == "" {
+		return "", ErrIndexNotExists
+	}
+	if elements[1] == "" {
+		return "", ErrRecurrenceNotExists
+	}
+
+	rule, err := parseRecurrence(elements[1])
+	if err != nil {
+		return "", err
+	}
+
+	_, err = modifyTask(store, elements[0], func(task *models.Task) {
+		task.Recur = rule
+		task.UpdatedAt = timeNow()
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return "Task not found", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("modifyTask: %w", err)
+	}
+
+	err = refresh(store, tasks)
+	if err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	if rule == "" {
+		return "Recurrence removed", nil
+	}
+
+	return fmt.Sprintf("Task recurs %s", rule), nil
+}
@@ -0,0 +1,190 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/query"
+)
+
+var (
+	ErrReportNameNotExists error = errors.New("report name is missing from the passed arguments")
+	errBuiltinReport       error = errors.New("a built-in report cannot be deleted")
+)
+
+// reports — отчеты из настроек приложения.
+var reports []config.Report
+
+// SetReports задает отчеты из настроек. Встроенные отчеты (см. config.BuiltinReports) доступны всегда,
+// отчет из настроек с тем же названием заменяет встроенный.
+func SetReports(r []config.Report) {
+	reports = r
+}
+
+// hasReport сообщает, что среди отчетов list есть отчет с названием name.
+func hasReport(list []config.Report, name string) bool {
+	return slices.ContainsFunc(list, func(r config.Report) bool { return strings.EqualFold(r.Name, name) })
+}
+
+// allReports возвращает отчеты из настроек и встроенные отчеты, которые ими не заменены.
+func allReports() []config.Report {
+	result := slices.Clone(reports)
+	for _, builtin := range config.BuiltinReports() {
+		if !hasReport(reports, builtin.Name) {
+			result = append(result, builtin)
+		}
+	}
+
+	return result
+}
+
+// findReport возвращает отчет по названию без учета регистра.
+func findReport(name string) (config.Report, error) {
+	all := allReports()
+	i := slices.IndexFunc(all, func(r config.Report) bool { return strings.EqualFold(r.Name, name) })
+	if i < 0 {
+		names := make([]string, 0, len(all))
+		for _, report := range all {
+			names = append(names, report.Name)
+		}
+		return config.Report{}, fmt.Errorf("%w: %s (available: %s)", config.ErrReportNotFound, name, strings.Join(names, ", "))
+	}
+
+	return all[i], nil
+}
+
+// checkReport проверяет фильтр, ключи сортировки, группировку и колонки отчета перед сохранением.
+func checkReport(report config.Report) error {
+	if report.Name == "" {
+		return ErrReportNameNotExists
+	}
+
+	_, err := query.Parse(report.Filter, queryEnv())
+	if err != nil {
+		return err
+	}
+	_, err = parseSort(report.Sort)
+	if err != nil {
+		return err
+	}
+	_, err = groupTasks(nil, report.GroupBy)
+	if err != nil {
+		return err
+	}
+	_, err = selectColumns(report.Columns)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RunReport передает в функцию для вывода в терминал список задач отчета name. Ключи сортировки, группировка
+// и колонки из opts заменяют заданные в отчете, а фильтр, статусы, теги и проект из opts дополняют фильтр отчета.
+func RunReport(tasks []models.Task, name string, opts ListOptions) error {
+	if name == "" {
+		return ErrReportNameNotExists
+	}
+
+	report, err := findReport(name)
+	if err != nil {
+		return err
+	}
+
+	// Фильтр из opts уточняет фильтр отчета.
+	switch {
+	case opts.Filter == "":
+		opts.Filter = report.Filter
+	case report.Filter != "":
+		opts.Filter = fmt.Sprintf("(%s) and (%s)", report.Filter, opts.Filter)
+	}
+	if opts.Sort == "" {
+		opts.Sort = report.Sort
+	}
+	if opts.GroupBy == "" {
+		opts.GroupBy = report.GroupBy
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = report.Columns
+	}
+
+	err = printTasks(tasks, tasks, opts)
+	if err != nil {
+		return fmt.Errorf("report %s: %w", report.Name, err)
+	}
+	return nil
+}
+
+// ReportsList возвращает описание всех отчетов: название, описание и параметры. Встроенные отчеты отмечаются.
+func ReportsList() string {
+	var resBuild strings.Builder
+	for _, report := range allReports() {
+		resBuild.WriteString(report.Name)
+		if !hasReport(reports, report.Name) {
+			resBuild.WriteString(" (built-in)")
+		}
+		if report.Description != "" {
+			resBuild.WriteString(": " + report.Description)
+		}
+		resBuild.WriteString("\n")
+
+		for _, param := range []struct{ name, value string }{
+			{"Filter", report.Filter},
+			{"Sort", report.Sort},
+			{"Group by", report.GroupBy},
+			{"Columns", strings.Join(report.Columns, ",")},
+		} {
+			if param.value != "" {
+				resBuild.WriteString(fmt.Sprintf("%s%s: %s\n", treeIndent, param.name, param.value))
+			}
+		}
+	}
+
+	return strings.TrimSuffix(resBuild.String(), "\n")
+}
+
+// SaveReport проверяет отчет и сохраняет его в файл настроек path. После чего возвращает сообщение
+// о результате действия или ошибку.
+func SaveReport(path string, report config.Report) (string, error) {
+	err := checkReport(report)
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := config.SaveReport(path, report)
+	if err != nil {
+		return "", fmt.Errorf("config.SaveReport: %w", err)
+	}
+	SetReports(saved)
+
+	return fmt.Sprintf("Report %s saved", report.Name), nil
+}
+
+// DeleteReport удаляет отчет из файла настроек path. Встроенные отчеты удалить нельзя, но можно удалить
+// отчет из настроек, который заменяет встроенный. После чего возвращает сообщение о результате действия или ошибку.
+func DeleteReport(path string, name string) (string, error) {
+	if name == "" {
+		return "", ErrReportNameNotExists
+	}
+
+	saved, err := config.DeleteReport(path, name)
+	if errors.Is(err, config.ErrReportNotFound) {
+		if hasReport(config.BuiltinReports(), name) {
+			return "", fmt.Errorf("%w: %s", errBuiltinReport, name)
+		}
+		return "Report not found", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("config.DeleteReport: %w", err)
+	}
+	SetReports(saved)
+
+	if hasReport(config.BuiltinReports(), name) {
+		return fmt.Sprintf("Report %s deleted, the built-in report is used again", name), nil
+	}
+	return fmt.Sprintf("Report %s deleted", name), nil
+}
@@ -0,0 +1,310 @@
+package filemanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// currentVersion — версия формата файла задач, которую записывает эта сборка приложения.
+const currentVersion = 12
+
+var ErrNewerVersion error = errors.New("the tasks file was written by a newer version of the application")
+
+// fileData описывает содержимое файла задач: версию формата, счетчик номеров задач и сами задачи.
+type fileData struct {
+	Version   int           `json:"version"`
+	NextIndex int           `json:"next_index"`
+	Tasks     []models.Task `json:"tasks"`
+}
+
+// taskList преобразует содержимое файла в список задач со счетчиком номеров.
+func (f fileData) taskList() TaskList {
+	list := TaskList{
+		Tasks:     f.Tasks,
+		NextIndex: f.NextIndex,
+	}
+	list.normalize()
+
+	return list
+}
+
+// document — файл задач в виде произвольного JSON-объекта. Миграции работают с ним,
+// а не с models.Task, чтобы не зависеть от текущего вида структуры.
+type document map[string]any
+
+// migration переводит документ из версии N в версию N+1.
+type migration func(doc document) error
+
+// migrations возвращает миграции по версии, из которой они переводят документ.
+// Номера статусов старых версий заменяются статусами из w.
+// Версия 0 — исходный формат файла: JSON-массив задач без заголовка.
+func migrations(w config.Workflow) map[int]migration {
+	return map[int]migration{
+		0:  migrateBareArray,
+		1:  migrateTaskIDs,
+		2:  migrateTimestamps,
+		3:  noChanges, // добавлено необязательное поле due
+		4:  noChanges, // добавлено необязательное поле priority
+		5:  noChanges, // добавлены необязательные поля tags и project
+		6:  noChanges, // добавлено необязательное поле parent_id
+		7:  noChanges, // добавлено необязательное поле depends_on
+		8:  noChanges, // добавлены необязательные поля recur и series_id
+		9:  noChanges, // добавлены необязательные поля description и notes
+		10: migrateStatusNames(w),
+		11: noChanges, // добавлены необязательные поля archived_at и deleted_at
+	}
+}
+
+// Номера статусов задач до версии 11.
+const (
+	legacyStatusTodo       = 0
+	legacyStatusInProgress = 1
+	legacyStatusDone       = 2
+)
+
+// noChanges используется для версий, в которых добавлены только необязательные поля.
+// Документ не меняется, а новая версия нужна, чтобы старые сборки не перезаписали файл, потеряв эти поля.
+func noChanges(doc document) error {
+	return nil
+}
+
+// migrateBareArray оборачивает массив задач версии 0 в объект с полем tasks.
+func migrateBareArray(doc document) error {
+	if _, ok := doc["tasks"]; !ok {
+		doc["tasks"] = []any{}
+	}
+
+	return nil
+}
+
+// migrateTaskIDs присваивает задачам уникальные ID и заводит счетчик номеров,
+// который больше номера любой существующей задачи.
+func migrateTaskIDs(doc document) error {
+	tasks, ok := doc["tasks"].([]any)
+	if !ok {
+		return errors.New("tasks is not an array")
+	}
+
+	nextIndex := 1
+	for _, item := range tasks {
+		task, ok := item.(map[string]any)
+		if !ok {
+			return errors.New("task is not an object")
+		}
+
+		if id, _ := task["id"].(string); id == "" {
+			task["id"] = newID()
+		}
+		if index, ok := task["index"].(float64); ok && int(index) >= nextIndex {
+			nextIndex = int(index) + 1
+		}
+	}
+
+	doc["next_index"] = nextIndex
+	return nil
+}
+
+// migrateTimestamps заполняет время создания и изменения задач, созданных до появления временных меток.
+// Настоящее время создания неизвестно, поэтому используется время миграции.
+func migrateTimestamps(doc document) error {
+	tasks, ok := doc["tasks"].([]any)
+	if !ok {
+		return errors.New("tasks is not an array")
+	}
+
+	at := timeNow().Format(time.RFC3339)
+	for _, item := range tasks {
+		task, ok := item.(map[string]any)
+		if !ok {
+			return errors.New("task is not an object")
+		}
+
+		if _, ok := task["created_at"]; !ok {
+			task["created_at"] = at
+		}
+		if _, ok := task["updated_at"]; !ok {
+			task["updated_at"] = at
+		}
+	}
+
+	return nil
+}
+
+// legacyStatusName возвращает статус из w, соответствующий номеру статуса до версии 11: начальный статус,
+// первый начатый статус или первый завершающий статус. Если в w нет начатого статуса, возвращается ошибка.
+func legacyStatusName(w config.Workflow, number float64) (string, error) {
+	switch number {
+	case legacyStatusTodo:
+		return w.Initial().Name, nil
+	case legacyStatusInProgress:
+		for _, status := range w[1:] {
+			if !status.Done {
+				return status.Name, nil
+			}
+		}
+		return "", errors.New("the configured statuses have no in-progress status for legacy status 1, " +
+			"add a status that is neither initial nor done")
+	case legacyStatusDone:
+		for _, status := range w {
+			if status.Done {
+				return status.Name, nil
+			}
+		}
+		return "", errors.New("the configured statuses have no done status for legacy status 2")
+	}
+
+	return "", fmt.Errorf("unknown task status %v", number)
+}
+
+// migrateStatusNames возвращает миграцию, которая заменяет номера статусов задач названиями статусов из w
+// (см. legacyStatusName).
+func migrateStatusNames(w config.Workflow) migration {
+	return func(doc document) error {
+		tasks, ok := doc["tasks"].([]any)
+		if !ok {
+			return errors.New("tasks is not an array")
+		}
+
+		for _, item := range tasks {
+			task, ok := item.(map[string]any)
+			if !ok {
+				return errors.New("task is not an object")
+			}
+
+			number, ok := task["status"].(float64)
+			if !ok {
+				continue
+			}
+
+			name, err := legacyStatusName(w, number)
+			if err != nil {
+				return err
+			}
+			task["status"] = name
+		}
+
+		return nil
+	}
+}
+
+// fileVersion определяет версию формата по содержимому файла. Пустой файл считается файлом текущей версии.
+func fileVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return currentVersion, nil
+	}
+	if data[0] == '[' {
+		return 0, nil
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	err := json.Unmarshal(data, &header)
+	if err != nil {
+		return 0, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return header.Version, nil
+}
+
+// decodeFile разбирает содержимое файла задач любой известной версии и приводит его к текущей версии.
+// Вторым значением возвращается версия, в которой файл был записан.
+// Файлы более новой версии читаются как есть, без миграций. Номера статусов старых версий
+// заменяются статусами из w.
+func decodeFile(data []byte, w config.Workflow) (fileData, int, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return fileData{}, 0, fmt.Errorf("fileVersion: %w", err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fileData{Version: currentVersion, Tasks: make([]models.Task, 0)}, version, nil
+	}
+
+	if version >= currentVersion {
+		var result fileData
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			return fileData{}, 0, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if result.Tasks == nil {
+			result.Tasks = make([]models.Task, 0)
+		}
+
+		return result, version, nil
+	}
+
+	doc := document{}
+	if version == 0 {
+		var tasks []any
+		err = json.Unmarshal(data, &tasks)
+		doc["tasks"] = tasks
+	} else {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return fileData{}, 0, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	err = migrate(doc, version, w)
+	if err != nil {
+		return fileData{}, 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	// Документ уже приведен к текущей версии, поэтому его можно разобрать в fileData обычным способом.
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return fileData{}, 0, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	var result fileData
+	err = json.Unmarshal(migrated, &result)
+	if err != nil {
+		return fileData{}, 0, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if result.Tasks == nil {
+		result.Tasks = make([]models.Task, 0)
+	}
+
+	return result, version, nil
+}
+
+// migrate последовательно применяет к документу миграции, начиная с версии from, до текущей версии.
+func migrate(doc document, from int, w config.Workflow) error {
+	steps := migrations(w)
+	for version := from; version < currentVersion; version++ {
+		step, ok := steps[version]
+		if !ok {
+			return fmt.Errorf("no migration from version %d", version)
+		}
+
+		err := step(doc)
+		if err != nil {
+			return fmt.Errorf("migration from version %d: %w", version, err)
+		}
+	}
+
+	doc["version"] = currentVersion
+	return nil
+}
+
+// encodeFile преобразует задачи в содержимое файла текущей версии.
+func encodeFile(list TaskList) ([]byte, error) {
+	list.normalize()
+	if list.Tasks == nil {
+		list.Tasks = make([]models.Task, 0)
+	}
+
+	return json.MarshalIndent(fileData{
+		Version:   currentVersion,
+		NextIndex: list.NextIndex,
+		Tasks:     list.Tasks,
+	}, "", "\t")
+}
@@ -0,0 +1,299 @@
+package filemanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+func TestDecodeFileMigrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+		wantNext    int
+		wantStatus  []models.TaskStatus
+	}{
+		{
+			name:        "bare array",
+			data:        `[{"index":1,"name":"a","status":0},{"index":3,"name":"b","status":1},{"index":2,"name":"c","status":2}]`,
+			wantVersion: 0,
+			wantNext:    4,
+			wantStatus:  []models.TaskStatus{"todo", "in-progress", "done"},
+		},
+		{
+			name:        "version 1 without IDs",
+			data:        `{"version":1,"tasks":[{"index":1,"name":"a","status":2}]}`,
+			wantVersion: 1,
+			wantNext:    2,
+			wantStatus:  []models.TaskStatus{"done"},
+		},
+		{
+			name:        "version 10 with status numbers",
+			data:        `{"version":10,"next_index":5,"tasks":[{"id":"aaaa1111-0000-4000-8000-000000000001","index":1,"name":"a","status":1}]}`,
+			wantVersion: 10,
+			wantNext:    5,
+			wantStatus:  []models.TaskStatus{"in-progress"},
+		},
+		{
+			name:        "current version",
+			data:        `{"version":12,"next_index":2,"tasks":[{"id":"aaaa1111-0000-4000-8000-000000000001","index":1,"name":"a","status":"done"}]}`,
+			wantVersion: 12,
+			wantNext:    2,
+			wantStatus:  []models.TaskStatus{"done"},
+		},
+		{
+			name:        "empty file",
+			data:        "",
+			wantVersion: currentVersion,
+			wantNext:    1,
+			wantStatus:  []models.TaskStatus{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, version, err := decodeFile([]byte(tt.data), config.Default().Statuses)
+			if err != nil {
+				t.Fatalf("decodeFile: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+
+			list := file.taskList()
+			if list.NextIndex != tt.wantNext {
+				t.Errorf("next index = %d, want %d", list.NextIndex, tt.wantNext)
+			}
+
+			statuses := make([]models.TaskStatus, 0, len(list.Tasks))
+			for _, task := range list.Tasks {
+				statuses = append(statuses, task.Status)
+				if task.ID == "" {
+					t.Errorf("task %d has no ID after migration", task.Index)
+				}
+				// Временные метки заполняет миграция из версии 2; в файлах новее они необязательны.
+				if version <= 2 && (task.CreatedAt.IsZero() || task.UpdatedAt.IsZero()) {
+					t.Errorf("task %d has no timestamps after migration", task.Index)
+				}
+			}
+			if !slices.Equal(statuses, tt.wantStatus) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDecodeFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"unknown status number", `[{"index":1,"name":"a","status":5}]`, "unknown task status 5"},
+		{"broken JSON", `{"version":12,"tasks":[`, "unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeFile([]byte(tt.data), config.Default().Statuses)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decodeFile error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateStatusNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow config.Workflow
+		want     []models.TaskStatus
+		wantErr  bool
+	}{
+		{
+			name:     "default statuses",
+			workflow: config.Default().Statuses,
+			want:     []models.TaskStatus{"todo", "in-progress", "done"},
+		},
+		{
+			name: "custom statuses",
+			workflow: config.Workflow{
+				{Name: "backlog"},
+				{Name: "doing"},
+				{Name: "review"},
+				{Name: "closed", Done: true},
+				{Name: "won't do", Done: true},
+			},
+			want: []models.TaskStatus{"backlog", "doing", "closed"},
+		},
+		{
+			name: "no in-progress status",
+			workflow: config.Workflow{
+				{Name: "open"},
+				{Name: "closed", Done: true},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := document{"tasks": []any{
+				map[string]any{"status": float64(0)},
+				map[string]any{"status": float64(1)},
+				map[string]any{"status": float64(2)},
+			}}
+			err := migrateStatusNames(tt.workflow)(doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrateStatusNames error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got []models.TaskStatus
+			for _, task := range doc["tasks"].([]any) {
+				got = append(got, models.TaskStatus(task.(map[string]any)["status"].(string)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("statuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONStoreMigratesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := []byte(`[{"index":1,"name":"a","status":0},{"index":2,"name":"b","status":2}]`)
+	err := os.WriteFile(path, legacy, 0644)
+	if err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	store, err := NewJSONStore(Location{Path: path})
+	if err != nil {
+		t.Fatalf("NewJSONStore: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("the original file must be kept in a backup: %v", err)
+	}
+	if string(backup) != string(legacy) {
+		t.Errorf("backup = %s, want %s", backup, legacy)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+	version, err := fileVersion(data)
+	if err != nil || version != currentVersion {
+		t.Errorf("version of the migrated file = %d (%v), want %d", version, err, currentVersion)
+	}
+
+	tasks, err := store.List()
+	if err != nil {
+		t.Fatalf("store.List: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Status != "todo" || tasks[1].Status != "done" {
+		t.Errorf("tasks = %+v, want a todo task and a done task", tasks)
+	}
+}
+
+func TestJSONStoreRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	err := os.WriteFile(path, []byte(`{"version":99,"next_index":2,"tasks":[{"index":1,"name":"a","status":"todo"}]}`), 0644)
+	if err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	store, err := NewJSONStore(Location{Path: path})
+	if err != nil {
+		t.Fatalf("NewJSONStore: %v", err)
+	}
+
+	tasks, err := store.List()
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("a newer file must stay readable: tasks = %v, error = %v", tasks, err)
+	}
+
+	err = store.Modify(func(list *TaskList) error {
+		list.NewTask(models.Task{Name: "b"})
+		return nil
+	})
+	if !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("store.Modify error = %v, want %v", err, ErrNewerVersion)
+	}
+}
+
+// TestJSONStoreMigratedIDsAreStable проверяет, что ID, присвоенные задачам при миграции, записываются
+// и не меняются при чтении файла задач или его резервной копии.
+func TestJSONStoreMigratedIDsAreStable(t *testing.T) {
+	legacy := []byte(`[{"index":1,"name":"a","status":0},{"index":2,"name":"b","status":2}]`)
+
+	taskIDs := func(t *testing.T, store Store) []string {
+		t.Helper()
+
+		tasks, err := store.List()
+		if err != nil {
+			t.Fatalf("store.List: %v", err)
+		}
+		ids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("tasks file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tasks.json")
+		err := os.WriteFile(path, legacy, 0644)
+		if err != nil {
+			t.Fatalf("os.WriteFile: %v", err)
+		}
+
+		store, err := NewJSONStore(Location{Path: path})
+		if err != nil {
+			t.Fatalf("NewJSONStore: %v", err)
+		}
+		want := taskIDs(t, store)
+
+		// Файл задач поврежден: задачи читаются из резервной копии с теми же ID.
+		err = os.WriteFile(path, []byte("{"), 0644)
+		if err != nil {
+			t.Fatalf("os.WriteFile: %v", err)
+		}
+		if got := taskIDs(t, store); !slices.Equal(got, want) {
+			t.Errorf("IDs from the backup = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tasks.json")
+		err := os.WriteFile(path, []byte("{"), 0644)
+		if err != nil {
+			t.Fatalf("os.WriteFile: %v", err)
+		}
+		err = os.WriteFile(backupPath(path), legacy, 0644)
+		if err != nil {
+			t.Fatalf("os.WriteFile: %v", err)
+		}
+
+		store, err := NewJSONStore(Location{Path: path})
+		if err != nil {
+			t.Fatalf("NewJSONStore: %v", err)
+		}
+		first, second := taskIDs(t, store), taskIDs(t, store)
+		if len(first) != 2 || !slices.Equal(first, second) {
+			t.Errorf("IDs from the backup changed between reads: %v, %v", first, second)
+		}
+	})
+}
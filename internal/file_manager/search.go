@@ -0,0 +1,366 @@
+package filemanager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/terminal"
+)
+
+var ErrSearchTermsNotExists error = errors.New("search terms are missing from the passed arguments")
+
+const (
+	colorHighlight = "\x1b[1;33m"
+	// snippetLength — длина фрагмента описания или заметки, который выводится в результатах поиска.
+	snippetLength = 80
+)
+
+// Оценки совпадения слова запроса со словом задачи.
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.9
+	scoreSubstring = 0.7
+	// scoreTypo — оценка совпадения с одной опечаткой. Каждая следующая опечатка снижает оценку на ту же величину.
+	scoreTypo = 0.25
+)
+
+// searchField — текстовое поле задачи, по которому выполняется поиск, и вес совпадения в нем.
+type searchField struct {
+	label  string
+	text   string
+	weight float64
+}
+
+// searchWord — слово текста в нормализованном виде и его положение в исходном тексте (в символах).
+type searchWord struct {
+	text       []rune
+	start, end int
+}
+
+// span — выделяемый фрагмент текста в символах.
+type span struct {
+	start, end int
+}
+
+// searchResult — найденная задача, ее оценка и совпавшие фрагменты по номерам полей.
+type searchResult struct {
+	task    models.Task
+	score   float64
+	fields  []searchField
+	matches map[int][]span
+}
+
+// foldRune приводит символ к нижнему регистру и заменяет ё на е, чтобы поиск не зависел от их написания.
+func foldRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if r == 'ё' {
+		return 'е'
+	}
+
+	return r
+}
+
+// searchWords разбивает текст на слова из букв и цифр любого алфавита.
+func searchWords(text string) []searchWord {
+	var (
+		words   []searchWord
+		current []rune
+		start   int
+	)
+
+	i := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if len(current) == 0 {
+				start = i
+			}
+			current = append(current, foldRune(r))
+		} else if len(current) > 0 {
+			words = append(words, searchWord{text: current, start: start, end: i})
+			current = nil
+		}
+		i++
+	}
+	if len(current) > 0 {
+		words = append(words, searchWord{text: current, start: start, end: i})
+	}
+
+	return words
+}
+
+// maxTypos возвращает допустимое количество опечаток для слова запроса: короткие слова должны совпадать точно.
+func maxTypos(term []rune) int {
+	switch {
+	case len(term) <= 3:
+		return 0
+	case len(term) <= 6:
+		return 1
+	}
+
+	return 2
+}
+
+// editDistance возвращает расстояние между словами с учетом перестановки соседних символов
+// (optimal string alignment): вставка, удаление, замена и перестановка считаются одной опечаткой.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, current = prev, current, prev2
+	}
+
+	return prev[len(b)]
+}
+
+// matchWord оценивает совпадение слова запроса со словом задачи и возвращает совпавший фрагмент слова.
+// Опечатки ищутся как во всем слове, так и в его начале такой же длины, как слово запроса.
+func matchWord(term []rune, word searchWord) (float64, span, bool) {
+	text := word.text
+	switch {
+	case slices.Equal(term, text):
+		return scoreExact, span{word.start, word.end}, true
+	case len(term) >= 2 && len(term) < len(text) && slices.Equal(term, text[:len(term)]):
+		return scorePrefix, span{word.start, word.start + len(term)}, true
+	}
+
+	if len(term) >= 3 {
+		if i := strings.Index(string(text), string(term)); i >= 0 {
+			start := word.start + len([]rune(string(text)[:i]))
+			return scoreSubstring, span{start, start + len(term)}, true
+		}
+	}
+
+	limit := maxTypos(term)
+	if limit == 0 {
+		return 0, span{}, false
+	}
+
+	distance, matched := editDistance(term, text), span{word.start, word.end}
+	if len(text) > len(term) {
+		if d := editDistance(term, text[:len(term)]); d < distance {
+			distance, matched = d, span{word.start, word.start + len(term)}
+		}
+	}
+	if distance > limit {
+		return 0, span{}, false
+	}
+
+	return scoreSubstring - float64(distance)*scoreTypo, matched, true
+}
+
+// taskSearchFields возвращает поля задачи, по которым выполняется поиск. Совпадение в названии весит больше всего.
+func taskSearchFields(task models.Task) []searchField {
+	fields := []searchField{
+		{label: "Name", text: task.Name, weight: 3},
+		{label: "Tags", text: strings.Join(task.Tags, ", "), weight: 2},
+		{label: "Description", text: task.Description, weight: 1.5},
+	}
+	for _, note := range task.Notes {
+		fields = append(fields, searchField{label: "Note " + dates.Format(note.At), text: note.Text, weight: 1})
+	}
+
+	return fields
+}
+
+// searchTask проверяет, что каждое слово запроса встречается в одном из полей задачи, и вычисляет оценку задачи
+// как сумму лучших оценок слов запроса.
+func searchTask(task models.Task, terms [][]rune) (searchResult, bool) {
+	result := searchResult{
+		task:    task,
+		fields:  taskSearchFields(task),
+		matches: make(map[int][]span),
+	}
+
+	words := make([][]searchWord, len(result.fields))
+	for i, field := range result.fields {
+		words[i] = searchWords(field.text)
+	}
+
+	for _, term := range terms {
+		best := 0.0
+		for i, field := range result.fields {
+			for _, word := range words[i] {
+				score, matched, ok := matchWord(term, word)
+				if !ok {
+					continue
+				}
+				result.matches[i] = append(result.matches[i], matched)
+				best = max(best, score*field.weight)
+			}
+		}
+		if best == 0 {
+			return searchResult{}, false
+		}
+		result.score += best
+	}
+
+	return result, true
+}
+
+// highlight выделяет в тексте фрагменты spans цветом (если colored) и возвращает часть текста [from, to) в символах.
+func highlight(text string, spans []span, from, to int, colored bool) string {
+	runes := []rune(text)
+	marked := make([]bool, len(runes))
+	for _, s := range spans {
+		for i := max(s.start, 0); i < min(s.end, len(runes)); i++ {
+			marked[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		if colored && marked[i] && (i == from || !marked[i-1]) {
+			b.WriteString(colorHighlight)
+		}
+		r := runes[i]
+		if r == '\n' || r == '\t' {
+			r = ' '
+		}
+		b.WriteRune(r)
+		if colored && marked[i] && (i == to-1 || !marked[i+1]) {
+			b.WriteString(colorReset)
+		}
+	}
+
+	return b.String()
+}
+
+// snippet возвращает фрагмент длинного текста вокруг первого совпадения с выделенными совпадениями.
+func snippet(text string, spans []span, colored bool) string {
+	runes := []rune(text)
+	length := len(runes)
+	if length <= snippetLength {
+		return highlight(text, spans, 0, length, colored)
+	}
+
+	first := slices.MinFunc(spans, func(a, b span) int { return cmp.Compare(a.start, b.start) })
+	from := max(0, min(first.start-snippetLength/4, length-snippetLength))
+	// Фрагмент начинается с начала слова, если оно недалеко.
+	for i := from; i > 0 && i > from-snippetLength/8; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			from = i
+			break
+		}
+	}
+	to := min(from+snippetLength, length)
+
+	result := highlight(text, spans, from, to, colored)
+	if from > 0 {
+		result = "…" + result
+	}
+	if to < length {
+		result += "…"
+	}
+
+	return result
+}
+
+// searchResults находит задачи по запросу и упорядочивает их по убыванию оценки, при равенстве — по срочности.
+func searchResults(tasks []models.Task, terms string) []searchResult {
+	var query [][]rune
+	for _, word := range searchWords(terms) {
+		query = append(query, word.text)
+	}
+	if len(query) == 0 {
+		return nil
+	}
+
+	var results []searchResult
+	for _, task := range tasks {
+		if result, ok := searchTask(task, query); ok {
+			results = append(results, result)
+		}
+	}
+
+	at := timeNow()
+	slices.SortStableFunc(results, func(a, b searchResult) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(Urgency(b.task, at), Urgency(a.task, at)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.task.Index, b.task.Index)
+	})
+
+	return results
+}
+
+// SearchTasks реализует поиск задач по словам запроса в названии, тегах, описании и заметках без учета регистра.
+// Слова допускается писать с опечатками: в словах от 4 символов допускается одна опечатка, от 7 — две.
+// Найденные задачи выводятся в терминал по убыванию релевантности, совпавшие фрагменты выделяются цветом.
+// В машиночитаемом формате (см. ListOptions.Format) для задач выводятся релевантность и все колонки списка.
+func SearchTasks(tasks []models.Task, terms string, format string) error {
+	if strings.TrimSpace(terms) == "" {
+		return ErrSearchTermsNotExists
+	}
+
+	format, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	results := searchResults(tasks, terms)
+	if format != FormatText {
+		at := timeNow()
+		header := append([]string{"score"}, columnNames()...)
+		records := make([]record, 0, len(results))
+		for _, result := range results {
+			r := record{{"score", math.Round(result.score*100) / 100}}
+			for _, c := range columns {
+				r = append(r, recordField{c.name, c.field(tasks, result.task, at)})
+			}
+			records = append(records, r)
+		}
+		return writeFormatted(os.Stdout, format, header, records)
+	}
+	if len(results) == 0 {
+		fmt.Println("No tasks found")
+		return nil
+	}
+
+	colored := terminal.IsTerminal(os.Stdout)
+	var resBuild strings.Builder
+	for _, result := range results {
+		task := result.task
+		name := highlight(task.Name, result.matches[0], 0, len([]rune(task.Name)), colored)
+		resBuild.WriteString(fmt.Sprintf("Index: %d\tID: %s\tName: %s\tStatus: %s\tScore: %.2f\n",
+			task.Index, ShortID(task), name, statusName(task.Status), result.score))
+
+		for i, field := range result.fields[1:] {
+			if spans := result.matches[i+1]; len(spans) > 0 {
+				resBuild.WriteString(fmt.Sprintf("%s%s: %s\n", treeIndent, field.label, snippet(field.text, spans, colored)))
+			}
+		}
+	}
+
+	err = page(resBuild.String())
+	if err != nil {
+		return fmt.Errorf("page: %w", err)
+	}
+	return nil
+}
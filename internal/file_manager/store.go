@@ -0,0 +1,139 @@
+package filemanager
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var ErrTaskNotFound error = errors.New("task not found")
+
+// Store описывает хранилище задач, через которое работают все команды пакета.
+// Реализации отвечают только за сохранение задач и ничего не знают о пользовательских командах.
+type Store interface {
+	// Load считывает из хранилища актуальный список задач.
+	Load() ([]models.Task, error)
+	// Save полностью перезаписывает хранилище переданным списком задач.
+	Save(tasks []models.Task) error
+	// Modify атомарно относительно других процессов перечитывает задачи вместе с архивом и счетчиком номеров,
+	// передает их в fn и сохраняет результат. Ошибка fn отменяет изменение.
+	Modify(fn func(list *TaskList) error) error
+	// Get возвращает задачу по ее индексу или ErrTaskNotFound.
+	Get(index int) (models.Task, error)
+	// Put добавляет задачу или заменяет существующую задачу с тем же индексом.
+	Put(task models.Task) error
+	// Delete удаляет задачу по индексу или возвращает ErrTaskNotFound.
+	Delete(index int) error
+	// List возвращает копию списка задач в порядке хранения. Задачи из архива и корзины не возвращаются.
+	List() ([]models.Task, error)
+	// ListArchived возвращает задачи из архива и корзины.
+	ListArchived() ([]models.Task, error)
+	// Watch возвращает канал, в который приходит сигнал при изменении хранилища другим процессом.
+	// Канал закрывается после отмены ctx.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+	// Where описывает, где хранятся задачи.
+	Where() string
+	// Undo отменяет последнюю операцию из журнала изменений хранилища и возвращает ее
+	// или ErrNothingToUndo.
+	Undo() (Operation, error)
+	// Redo повторно применяет последнюю отмененную операцию и возвращает ее или ErrNothingToRedo.
+	// Любое новое изменение хранилища очищает список отмененных операций.
+	Redo() (Operation, error)
+	// Audit возвращает записи журнала аудита не раньше since в порядке записи.
+	Audit(since time.Time) ([]AuditEntry, error)
+}
+
+// TaskList — задачи вместе со счетчиком номеров, который хранится в заголовке файла.
+// Счетчик только растет, поэтому номера удаленных задач не используются повторно.
+// Archived — задачи в архиве и в корзине, они хранятся отдельно от остальных задач.
+type TaskList struct {
+	Tasks     []models.Task
+	Archived  []models.Task
+	NextIndex int
+}
+
+// NewTask присваивает задаче следующий свободный номер и новый уникальный ID и добавляет ее в список.
+func (l *TaskList) NewTask(task models.Task) models.Task {
+	l.normalize()
+
+	task.Index = l.NextIndex
+	task.ID = newID()
+	l.NextIndex++
+
+	l.Tasks = append(l.Tasks, task)
+	return task
+}
+
+// all возвращает задачи вместе с задачами из архива и корзины.
+func (l *TaskList) all() []models.Task {
+	return slices.Concat(l.Tasks, l.Archived)
+}
+
+// clone возвращает копию списка, которую не затрагивают изменения исходного списка.
+func (l *TaskList) clone() TaskList {
+	return TaskList{
+		Tasks:     slices.Clone(l.Tasks),
+		Archived:  slices.Clone(l.Archived),
+		NextIndex: l.NextIndex,
+	}
+}
+
+// split распределяет задачи между списком и архивом по отметкам ArchivedAt и DeletedAt.
+func (l *TaskList) split(tasks []models.Task) {
+	l.Tasks, l.Archived = nil, nil
+	for _, task := range tasks {
+		if isArchived(task) {
+			l.Archived = append(l.Archived, task)
+		} else {
+			l.Tasks = append(l.Tasks, task)
+		}
+	}
+}
+
+// normalize гарантирует, что счетчик номеров больше номера любой существующей задачи, в том числе задачи из архива.
+func (l *TaskList) normalize() {
+	for _, task := range l.all() {
+		if task.Index >= l.NextIndex {
+			l.NextIndex = task.Index + 1
+		}
+	}
+
+	if l.NextIndex < 1 {
+		l.NextIndex = 1
+	}
+}
+
+// findTask возвращает позицию задачи с указанным индексом в слайсе или -1, если задачи нет.
+func findTask(tasks []models.Task, index int) int {
+	for i, task := range tasks {
+		if task.Index == index {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// putTask добавляет задачу в слайс или заменяет задачу с тем же индексом и возвращает обновленный слайс.
+func putTask(tasks []models.Task, task models.Task) []models.Task {
+	i := findTask(tasks, task.Index)
+	if i == -1 {
+		return append(tasks, task)
+	}
+
+	tasks[i] = task
+	return tasks
+}
+
+// deleteTask удаляет задачу с указанным индексом из слайса и возвращает обновленный слайс.
+func deleteTask(tasks []models.Task, index int) ([]models.Task, error) {
+	i := findTask(tasks, index)
+	if i == -1 {
+		return tasks, ErrTaskNotFound
+	}
+
+	return append(tasks[:i], tasks[i+1:]...), nil
+}
@@ -0,0 +1,155 @@
+package filemanager
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// testStores возвращает конструкторы хранилищ, на которых проверяется общее поведение Store.
+func testStores() map[string]func(t *testing.T) Store {
+	return map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+		"json": func(t *testing.T) Store {
+			store, err := NewJSONStore(Location{Path: filepath.Join(t.TempDir(), "tasks.json")})
+			if err != nil {
+				t.Fatalf("NewJSONStore: %v", err)
+			}
+			return store
+		},
+	}
+}
+
+// addTasks добавляет в хранилище задачи с указанными названиями.
+func addTasks(t *testing.T, store Store, names ...string) {
+	t.Helper()
+
+	err := store.Modify(func(list *TaskList) error {
+		for _, name := range names {
+			list.NewTask(models.Task{Name: name, Status: initialStatus()})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("store.Modify: %v", err)
+	}
+}
+
+// taskNames возвращает названия задач хранилища в порядке хранения.
+func taskNames(t *testing.T, store Store) []string {
+	t.Helper()
+
+	tasks, err := store.List()
+	if err != nil {
+		t.Fatalf("store.List: %v", err)
+	}
+
+	names := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+
+	return names
+}
+
+func TestStoreModify(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name    string
+		modify  func(list *TaskList) error
+		wantErr error
+		want    []string
+	}{
+		{
+			name: "add",
+			modify: func(list *TaskList) error {
+				list.NewTask(models.Task{Name: "c"})
+				return nil
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "rename",
+			modify: func(list *TaskList) error {
+				list.Tasks[0].Name = "renamed"
+				return nil
+			},
+			want: []string{"renamed", "b"},
+		},
+		{
+			name: "delete",
+			modify: func(list *TaskList) error {
+				list.Tasks = list.Tasks[1:]
+				return nil
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "error discards changes",
+			modify: func(list *TaskList) error {
+				list.Tasks[0].Name = "renamed"
+				list.NewTask(models.Task{Name: "c"})
+				return errStop
+			},
+			wantErr: errStop,
+			want:    []string{"a", "b"},
+		},
+	}
+
+	for storeName, newStore := range testStores() {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				store := newStore(t)
+				addTasks(t, store, "a", "b")
+
+				err := store.Modify(tt.modify)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("store.Modify error = %v, want %v", err, tt.wantErr)
+				}
+				if got := taskNames(t, store); !slices.Equal(got, tt.want) {
+					t.Errorf("tasks = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestMemoryStoreReturnsCopies(t *testing.T) {
+	store := NewMemoryStore()
+	err := store.Modify(func(list *TaskList) error {
+		list.NewTask(models.Task{Name: "a", Tags: []string{"x", "y"}, DependsOn: []string{"1", "2"}})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("store.Modify: %v", err)
+	}
+
+	tasks, err := store.List()
+	if err != nil {
+		t.Fatalf("store.List: %v", err)
+	}
+	tasks[0].Tags[0] = "changed"
+	_ = slices.DeleteFunc(tasks[0].DependsOn, func(id string) bool { return id == "1" })
+
+	err = store.Modify(func(list *TaskList) error {
+		list.Tasks[0].DependsOn = slices.DeleteFunc(list.Tasks[0].DependsOn, func(id string) bool { return id == "2" })
+		return errors.New("discard")
+	})
+	if err == nil {
+		t.Fatal("store.Modify must return the error of fn")
+	}
+
+	task, err := store.Get(1)
+	if err != nil {
+		t.Fatalf("store.Get: %v", err)
+	}
+	if !slices.Equal(task.Tags, []string{"x", "y"}) || !slices.Equal(task.DependsOn, []string{"1", "2"}) {
+		t.Errorf("stored task = %+v, changes of copies must not reach the store", task)
+	}
+}
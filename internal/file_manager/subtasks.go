@@ -0,0 +1,184 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// Режимы удаления задачи, у которой есть подзадачи.
+const (
+	// DeleteCascade удаляет задачу вместе со всеми подзадачами.
+	DeleteCascade = "cascade"
+	// DeletePromote удаляет только задачу, а ее подзадачи переходят к ее родителю.
+	DeletePromote = "promote"
+)
+
+var (
+	ErrHasSubtasks     error = errors.New("the task has subtasks, pass cascade to delete them or promote to keep them")
+	errIncorrectDelete error = errors.New("an incorrect delete mode was passed")
+	errParentNotFound  error = errors.New("parent task not found")
+)
+
+// treeItem — задача и ее глубина в дереве подзадач при выводе списка.
+type treeItem struct {
+	task  models.Task
+	depth int
+}
+
+// children возвращает прямые подзадачи задачи с указанным ID в порядке следования в слайсе.
+func children(tasks []models.Task, id string) []models.Task {
+	var result []models.Task
+	for _, task := range tasks {
+		if id != "" && task.ParentID == id {
+			result = append(result, task)
+		}
+	}
+
+	return result
+}
+
+// descendants возвращает все подзадачи задачи с указанным ID на любой глубине.
+func descendants(tasks []models.Task, id string) []models.Task {
+	var result []models.Task
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, child := range children(tasks, current) {
+			if visited[child.ID] {
+				continue
+			}
+			visited[child.ID] = true
+			result = append(result, child)
+			queue = append(queue, child.ID)
+		}
+	}
+
+	return result
+}
+
+// progress возвращает количество выполненных и общее количество подзадач задачи на любой глубине.
+func progress(tasks []models.Task, task models.Task) (int, int) {
+	subtasks := descendants(tasks, task.ID)
+
+	done := 0
+	for _, subtask := range subtasks {
+		if isDone(subtask) {
+			done++
+		}
+	}
+
+	return done, len(subtasks)
+}
+
+// buildTree раскладывает уже отсортированные задачи в дерево: каждая подзадача выводится сразу
+// после своего родителя с увеличенной глубиной. Задачи, родителя которых нет среди выводимых, становятся корнями.
+func buildTree(tasks []models.Task) []treeItem {
+	present := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	result := make([]treeItem, 0, len(tasks))
+	visited := make(map[string]bool, len(tasks))
+
+	var walk func(task models.Task, depth int)
+	walk = func(task models.Task, depth int) {
+		if visited[task.ID] {
+			return
+		}
+		visited[task.ID] = true
+		result = append(result, treeItem{task: task, depth: depth})
+
+		for _, child := range children(tasks, task.ID) {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, task := range tasks {
+		if task.ParentID == "" || !present[task.ParentID] {
+			walk(task, 0)
+		}
+	}
+
+	// Задачи из циклов без корня выводятся в конце без вложенности.
+	for _, task := range tasks {
+		if !visited[task.ID] {
+			walk(task, 0)
+		}
+	}
+
+	return result
+}
+
+// resolveParent находит будущего родителя задачи по ссылке пользователя и возвращает его ID.
+func resolveParent(tasks []models.Task, ref string) (string, error) {
+	i, err := resolveRef(tasks, ref)
+	if errors.Is(err, ErrTaskNotFound) {
+		return "", fmt.Errorf("%w: %s", errParentNotFound, ref)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return tasks[i].ID, nil
+}
+
+// removeTask переносит в корзину задачу с позицией i с учетом ее подзадач и возвращает количество удаленных задач.
+// Без режима удаление задачи с подзадачами запрещено. Зависимости других задач от удаленных сохраняются,
+// чтобы восстановленная задача снова их блокировала, и убираются при очистке корзины.
+func removeTask(list *TaskList, i int, mode string) (int, error) {
+	task := list.Tasks[i]
+	subtasks := descendants(list.Tasks, task.ID)
+
+	remove := map[string]bool{task.ID: true}
+	switch strings.ToLower(mode) {
+	case "":
+		if len(subtasks) > 0 {
+			return 0, ErrHasSubtasks
+		}
+	case DeleteCascade:
+		for _, subtask := range subtasks {
+			remove[subtask.ID] = true
+		}
+	case DeletePromote:
+		for j := range list.Tasks {
+			if list.Tasks[j].ParentID == task.ID {
+				list.Tasks[j].ParentID = task.ParentID
+			}
+		}
+	default:
+		return 0, fmt.Errorf("%w: %s", errIncorrectDelete, mode)
+	}
+
+	at := timeNow()
+	return moveToArchive(list, remove, func(task *models.Task) { task.DeletedAt = at }), nil
+}
+
+// ParentToComplete проверяет задачу, на которую указывает ссылка пользователя: если она выполнена,
+// а все подзадачи ее родителя тоже выполнены, возвращает этого родителя, чтобы предложить пользователю завершить его.
+func ParentToComplete(tasks []models.Task, ref string) (models.Task, bool) {
+	i, err := resolveRef(tasks, ref)
+	if err != nil || !isDone(tasks[i]) || tasks[i].ParentID == "" {
+		return models.Task{}, false
+	}
+
+	p := slices.IndexFunc(tasks, func(t models.Task) bool { return t.ID == tasks[i].ParentID })
+	if p == -1 || isDone(tasks[p]) {
+		return models.Task{}, false
+	}
+
+	done, total := progress(tasks, tasks[p])
+	if done != total {
+		return models.Task{}, false
+	}
+
+	return tasks[p], true
+}
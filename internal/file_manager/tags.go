@@ -0,0 +1,151 @@
+package filemanager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+const (
+	// projectSeparator разделяет уровни иерархического проекта.
+	projectSeparator = "."
+)
+
+var errIncorrectTag error = errors.New("an incorrect tag was passed")
+
+// applyTagChanges применяет к тегам задачи изменения, переданные через запятую:
+// "urgent,-later" добавляет тег urgent и удаляет тег later. Знак + перед тегом допускается.
+func applyTagChanges(tags []string, changes string) ([]string, error) {
+	tags = slices.Clone(tags)
+
+	for _, change := range strings.Split(changes, ",") {
+		change = strings.TrimSpace(change)
+		if change == "" {
+			continue
+		}
+
+		remove := strings.HasPrefix(change, "-")
+		tag := strings.TrimLeft(change, "+-")
+		if tag == "" || strings.ContainsAny(tag, " \t:") {
+			return nil, fmt.Errorf("%w: %s", errIncorrectTag, change)
+		}
+
+		if remove {
+			tags = slices.DeleteFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+		} else if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	return tags, nil
+}
+
+// normalizeProject убирает из названия проекта лишние пробелы и пустые уровни иерархии.
+func normalizeProject(project string) string {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSpace(project), projectSeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, projectSeparator)
+}
+
+// inProject сообщает, относится ли задача к проекту или к одному из его подпроектов.
+func inProject(task models.Task, project string) bool {
+	project = normalizeProject(project)
+
+	return strings.EqualFold(task.Project, project) ||
+		len(task.Project) > len(project) &&
+			strings.EqualFold(task.Project[:len(project)], project) &&
+			strings.HasPrefix(task.Project[len(project):], projectSeparator)
+}
+
+// hasTags сообщает, есть ли у задачи все перечисленные теги.
+func hasTags(task models.Task, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(task.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// summaryLine — строка сводки по тегу или проекту.
+type summaryLine struct {
+	name  string
+	total int
+	open  int
+}
+
+// printSummary выводит строки сводки в алфавитном порядке.
+func printSummary(title string, counts map[string]*summaryLine) {
+	lines := make([]*summaryLine, 0, len(counts))
+	for _, line := range counts {
+		lines = append(lines, line)
+	}
+	slices.SortFunc(lines, func(a, b *summaryLine) int { return cmp.Compare(a.name, b.name) })
+
+	if len(lines) == 0 {
+		fmt.Printf("No %s\n", title)
+		return
+	}
+
+	for _, line := range lines {
+		fmt.Printf("%s\tTasks: %d\tOpen: %d\n", line.name, line.total, line.open)
+	}
+}
+
+// count увеличивает счетчики строки сводки с указанным названием.
+func count(counts map[string]*summaryLine, name string, task models.Task) {
+	line, ok := counts[name]
+	if !ok {
+		line = &summaryLine{name: name}
+		counts[name] = line
+	}
+
+	line.total++
+	if !isDone(task) {
+		line.open++
+	}
+}
+
+// Tags выводит в терминал все теги с количеством всех и незавершенных задач для каждого из них.
+func Tags(tasks []models.Task) {
+	counts := make(map[string]*summaryLine)
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			count(counts, strings.ToLower(tag), task)
+		}
+	}
+
+	printSummary("tags", counts)
+}
+
+// Projects выводит в терминал все проекты с количеством всех и незавершенных задач.
+// Задачи подпроектов учитываются и в родительских проектах.
+func Projects(tasks []models.Task) {
+	counts := make(map[string]*summaryLine)
+	for _, task := range tasks {
+		if task.Project == "" {
+			continue
+		}
+
+		parts := strings.Split(task.Project, projectSeparator)
+		for i := range parts {
+			count(counts, strings.Join(parts[:i+1], projectSeparator), task)
+		}
+	}
+
+	printSummary("projects", counts)
+}
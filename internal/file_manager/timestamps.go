@@ -0,0 +1,79 @@
+package filemanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// timeNow возвращает текущее время. Вынесено в переменную, чтобы все метки одной операции совпадали
+// и чтобы время можно было подменить.
+var timeNow = func() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// setStatus меняет статус задачи и поддерживает временные метки переходов:
+// при переходе в начатый статус заполняется StartedAt, в завершающий — CompletedAt,
+// а при возврате к начальному или незавершенному статусу соответствующие метки очищаются.
+func setStatus(task *models.Task, status models.TaskStatus, at time.Time) {
+	if task.Status == status {
+		return
+	}
+
+	switch {
+	case status == initialStatus():
+		task.StartedAt = time.Time{}
+		task.CompletedAt = time.Time{}
+	case workflow.IsDone(string(status)):
+		if !isDone(*task) {
+			task.CompletedAt = at
+		}
+	default:
+		if task.StartedAt.IsZero() {
+			task.StartedAt = at
+		}
+		task.CompletedAt = time.Time{}
+	}
+
+	task.Status = status
+	task.UpdatedAt = at
+}
+
+// relativeTime возвращает время в коротком относительном виде: "5m ago", "3d ago", "in 2h".
+// Для нулевого времени возвращается "-".
+func relativeTime(t, at time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	d := at.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var value string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		value = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		value = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 14*24*time.Hour:
+		value = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d < 60*24*time.Hour:
+		value = fmt.Sprintf("%dw", int(d/(7*24*time.Hour)))
+	case d < 365*24*time.Hour:
+		value = fmt.Sprintf("%dmo", int(d/(30*24*time.Hour)))
+	default:
+		value = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+
+	if future {
+		return "in " + value
+	}
+
+	return value + " ago"
+}
@@ -0,0 +1,150 @@
+package filemanager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/config"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var errTransitionNotAllowed error = errors.New("the status transition is not allowed")
+
+// workflow — статусы задач из настроек приложения.
+var workflow = config.Default().Statuses
+
+// SetWorkflow задает статусы задач, с которыми работают все команды пакета.
+func SetWorkflow(w config.Workflow) {
+	workflow = w
+}
+
+// parseStatus проверяет переданный пользователем статус задачи (название или короткое название)
+// и возвращает название статуса из настроек.
+func parseStatus(element string) (models.TaskStatus, error) {
+	if element == "" {
+		return "", ErrStatusNotExists
+	}
+
+	status, ok := workflow.Find(trimQuotes(element))
+	if !ok {
+		return "", fmt.Errorf("%w: %s (available: %s)", errIncorrectStatus, element, strings.Join(workflow.Names(), ", "))
+	}
+
+	return models.TaskStatus(status.Name), nil
+}
+
+// statusGroups — группы статусов, которые можно указать в фильтре вместо названия статуса.
+var statusGroups = map[string]func(status models.TaskStatus) bool{
+	"new":     func(status models.TaskStatus) bool { return status == initialStatus() },
+	"open":    func(status models.TaskStatus) bool { return !workflow.IsDone(string(status)) },
+	"closed":  func(status models.TaskStatus) bool { return workflow.IsDone(string(status)) },
+	"started": isStarted,
+}
+
+// parseStatusFilter возвращает статусы, на которые указывает значение фильтра: название или короткое название
+// статуса либо группу статусов (new, open, closed, started).
+func parseStatusFilter(element string) ([]models.TaskStatus, error) {
+	status, err := parseStatus(element)
+	if err == nil {
+		return []models.TaskStatus{status}, nil
+	}
+
+	inGroup, ok := statusGroups[strings.ToLower(element)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (available: %s, new, open, closed, started)", errIncorrectStatus, element, strings.Join(workflow.Names(), ", "))
+	}
+
+	statuses := []models.TaskStatus{}
+	for _, status := range workflow {
+		if inGroup(models.TaskStatus(status.Name)) {
+			statuses = append(statuses, models.TaskStatus(status.Name))
+		}
+	}
+
+	return statuses, nil
+}
+
+// checkTransition возвращает ошибку, если настройки не разрешают перевести задачу из статуса from в статус to.
+func checkTransition(from, to models.TaskStatus) error {
+	if workflow.Allowed(string(from), string(to)) {
+		return nil
+	}
+
+	allowed := "none"
+	if status, ok := workflow.Find(string(from)); ok && len(status.Transitions) > 0 {
+		allowed = strings.Join(status.Transitions, ", ")
+	}
+
+	return fmt.Errorf("%w: %s -> %s (allowed: %s)", errTransitionNotAllowed, from, to, allowed)
+}
+
+// initialStatus возвращает статус новых задач.
+func initialStatus() models.TaskStatus {
+	return models.TaskStatus(workflow.Initial().Name)
+}
+
+// isDone сообщает, что задача находится в завершающем статусе.
+func isDone(task models.Task) bool {
+	return workflow.IsDone(string(task.Status))
+}
+
+// isStarted сообщает, что работа над задачей начата: задача не в начальном и не в завершающем статусе.
+func isStarted(status models.TaskStatus) bool {
+	return status != initialStatus() && !workflow.IsDone(string(status))
+}
+
+// statusName возвращает название статуса задачи для вывода в терминал.
+func statusName(status models.TaskStatus) string {
+	if status == "" {
+		return "Incorrect task status"
+	}
+
+	return string(status)
+}
+
+// StatusesHelp возвращает описание статусов из настроек для подсказки: названия, короткие названия
+// и отметку о завершающих статусах.
+func StatusesHelp() string {
+	descriptions := make([]string, 0, len(workflow))
+	for _, status := range workflow {
+		var details []string
+		if status.Key != "" {
+			details = append(details, status.Key)
+		}
+		if status.Done {
+			details = append(details, "counts as done")
+		}
+
+		description := status.Name
+		if len(details) > 0 {
+			description += " (" + strings.Join(details, ", ") + ")"
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// DoneStatuses возвращает названия статусов, в которых задача считается завершенной.
+func DoneStatuses() []string {
+	var names []string
+	for _, status := range workflow {
+		if status.Done {
+			names = append(names, status.Name)
+		}
+	}
+
+	return names
+}
+
+// statusOrder возвращает номер статуса в настройках. Статусы, которых нет в настройках, идут последними.
+func statusOrder(status models.TaskStatus) int {
+	for i, s := range workflow {
+		if strings.EqualFold(s.Name, string(status)) {
+			return i
+		}
+	}
+
+	return len(workflow)
+}
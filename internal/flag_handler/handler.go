@@ -1,149 +1,664 @@
 package flaghandler
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	filemanager "github.com/NikitaTumanov/terminalTaskTracker/internal/file_manager"
 	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/terminal"
 )
 
+// Options хранит флаги, переданные пользователем при запуске.
+type Options struct {
+	TaskIndex   string
+	Command     string
+	TaskName    string
+	TaskStatus  string
+	Help        bool
+	Sort        string
+	Due         string
+	Priority    string
+	Tags        string
+	Project     string
+	Parent      string
+	Subtasks    string
+	Depends     string
+	Recur       string
+	Description string
+	Note        string
+	Filter      string
+	GroupBy     string
+	Columns     string
+	Since       string
+	All         bool
+	Format      string
+	// Yes подтверждает массовое изменение задач без вопроса пользователю.
+	Yes bool
+	// Args — аргументы после флагов: фильтр команды list (-c list status:open +urgent) или запрос команды search.
+	Args string
+}
+
 // storage вместе с задачами хранит флаги, отправленные пользователем.
 type storage struct {
-	tasks      []models.Task
-	taskIndex  int
-	command    string
-	taskName   string
-	taskStatus string
-	helpFlag   bool
+	store filemanager.Store
+	mu    sync.Mutex
+	tasks []models.Task
+	opts  Options
 }
 
-func New(taskIndex int, command, taskName, taskStatus string, helpFlag bool) (*storage, error) {
-	tasks, err := filemanager.GetAllTasks()
+func New(store filemanager.Store, opts Options) (*storage, error) {
+	tasks, err := store.List()
 	if err != nil {
-		return &storage{}, fmt.Errorf("filemanager.GetAllTasks: %w", err)
+		return &storage{}, fmt.Errorf("store.List: %w", err)
 	}
 
 	return &storage{
-		tasks:      tasks,
-		taskIndex:  taskIndex,
-		command:    command,
-		taskName:   taskName,
-		taskStatus: taskStatus,
-		helpFlag:   helpFlag,
+		store: store,
+		tasks: tasks,
+		opts:  opts,
 	}, nil
 }
 
-// Update запускает горутину для обновления слайса в структуре актуальной информаией из JSON.
-func (s *storage) Update() {
-	go func() {
-		var err error
-		for {
-			time.Sleep(models.TimeOut)
-			s.tasks, err = filemanager.GetAllTasks()
-			if err != nil {
-				fmt.Println("filemanager.GetAllTasks: ", err)
-			}
-		}
-	}()
+// Update ничего не делает: при запуске с флагами выполняется одна команда, которая сама читает задачи
+// из хранилища, поэтому наблюдать за изменениями файла не нужно.
+func (s *storage) Update(ctx context.Context) error {
+	return nil
+}
+
+// snapshot возвращает копию текущих задач, чтобы вывод списка не удерживал блокировку.
+func (s *storage) snapshot() []models.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.tasks)
+}
+
+// listed возвращает задачи для вывода списка: с флагом --all к ним добавляются задачи из архива.
+func (s *storage) listed() ([]models.Task, error) {
+	tasks := s.snapshot()
+	if !s.opts.All {
+		return tasks, nil
+	}
+
+	return filemanager.WithArchived(s.store, tasks)
+}
+
+// listOptions возвращает параметры вывода списка задач из флагов пользователя.
+func (s *storage) listOptions() filemanager.ListOptions {
+	var tags []string
+	for _, tag := range strings.Split(s.opts.Tags, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "+"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	var statuses []string
+	if s.opts.TaskStatus != "" {
+		statuses = strings.Split(s.opts.TaskStatus, ",")
+	}
+
+	var columns []string
+	if s.opts.Columns != "" {
+		columns = strings.Split(s.opts.Columns, ",")
+	}
+
+	// Фильтр можно передать флагом --filter или аргументами после флагов.
+	filter := s.opts.Filter
+	if filter == "" {
+		filter = s.opts.Args
+	}
+
+	return filemanager.ListOptions{
+		Sort:     s.opts.Sort,
+		Tags:     tags,
+		Project:  s.opts.Project,
+		Statuses: statuses,
+		Filter:   filter,
+		GroupBy:  s.opts.GroupBy,
+		Columns:  columns,
+		All:      s.opts.All,
+		Format:   s.opts.Format,
+	}
+}
+
+// stdin — общий буферизованный ввод терминала для вопросов пользователю.
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm задает пользователю вопрос и возвращает true, если ответ начинается с y.
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, _ := stdin.ReadString('\n')
+
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
+// target возвращает цель команды: номер, префикс ID или список номеров из флага --index,
+// иначе фильтр из флага --filter или из аргументов после флагов.
+func (s *storage) target() string {
+	switch {
+	case s.opts.TaskIndex != "":
+		return s.opts.TaskIndex
+	case s.opts.Filter != "":
+		return s.opts.Filter
+	}
+
+	return s.opts.Args
+}
+
+// confirmTargets находит в хранилище задачи, на которые указывает цель команды, и возвращает их вместе с целью,
+// закрепленной за их ID (см. filemanager.PinTargets): операция изменит именно эти задачи или не изменит ничего.
+// Если задач больше filemanager.ConfirmThreshold и не передан флаг --yes, задачи выводятся и у пользователя
+// запрашивается подтверждение. Если ввод не подключен к терминалу или пользователь отказался,
+// возвращается filemanager.ErrNotConfirmed. Ошибки чтения и поиска задач возвращаются: без найденных задач
+// команда не выполняется.
+func (s *storage) confirmTargets(target, action string) (string, []models.Task, error) {
+	tasks, err := s.store.List()
+	if err != nil {
+		return "", nil, fmt.Errorf("store.List: %w", err)
+	}
+	targets, err := filemanager.Targets(tasks, target)
+	if err != nil {
+		return "", nil, fmt.Errorf("filemanager.Targets: %w", err)
+	}
+
+	if len(targets) > filemanager.ConfirmThreshold && !s.opts.Yes {
+		list, question := filemanager.TargetsSummary(targets, action)
+		if !terminal.IsTerminal(os.Stdin) {
+			fmt.Print(list)
+			return "", nil, fmt.Errorf("%w, pass --yes to confirm it", filemanager.ErrNotConfirmed)
+		}
+		if !confirm(list + question) {
+			return "", nil, filemanager.ErrNotConfirmed
+		}
+	}
+
+	return filemanager.PinTargets(targets), targets, nil
+}
+
+// completeTargets предлагает завершить родительские задачи измененных задач (см. completeParents).
+func (s *storage) completeTargets(targets []models.Task) {
+	for _, task := range targets {
+		s.completeParents(strconv.Itoa(task.Index))
+	}
+}
+
+// completeParents предлагает завершить родительскую задачу, если после изменения задачи ref
+// все подзадачи родителя выполнены. Вопрос задается, только если ввод подключен к терминалу,
+// иначе выводится подсказка. Проверка повторяется вверх по дереву.
+func (s *storage) completeParents(ref string) {
+	interactive := terminal.IsTerminal(os.Stdin)
+
+	for {
+		s.mu.Lock()
+		parent, ok := filemanager.ParentToComplete(s.tasks, ref)
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+
+		if !interactive {
+			fmt.Printf("All subtasks of task %d are done, it can be marked as done\n", parent.Index)
+			return
+		}
+
+		if !confirm(fmt.Sprintf("All subtasks of task %d are done. Mark it as done? [y/N]: ", parent.Index)) {
+			return
+		}
+
+		ref = strconv.Itoa(parent.Index)
+		s.mu.Lock()
+		result, err := filemanager.UpdateStatus(s.store, &s.tasks, []string{ref, filemanager.DoneStatuses()[0]})
+		s.mu.Unlock()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(result)
+	}
+}
+
+// reportAliases сопоставляет прежним командам вывода списков встроенные отчеты, которые их заменили.
+var reportAliases = map[string]string{
+	"alltasks":        "all",
+	"donetasks":       "done",
+	"notdonetasks":    "notdone",
+	"inprogresstasks": "inprogress",
 }
 
 // printHelp выводит подсказку при получении флага --help.
 func printHelp() {
-	fmt.Println(`	Add Task: -c add --name="<Task name>"
-	Update Task: -c update --index=<Task Index> --name="<New Task Name>" --status=<New Task Status>
-		Task Statuses:
-			0 - Not started
-			1 - In progress
-			2 - Done
-	Delete Task: -c delete --index=<Task Index>
-	Update Task Status: -c updateStatus --index=<Task Index> --status=<New Task Status>
-		Task Statuses:
-			0 - Not started
-			1 - In progress
-			2 - Done
-	Show All Tasks: -c allTasks
-	Show Done Tasks: -c doneTasks
-	Show Not Done Tasks: -c notDoneTasks
-	Show Tasks In Progress: -c inProgressTasks`)
+	statuses := filemanager.StatusesHelp()
+	fmt.Printf(`	Add Task: -c add --name="<Task name>" [--due="<Due Date>"] [--priority=<Priority>] [--tags=<Tag>,<Tag>] [--project=<Project>]
+		[--parent=<Parent Task Index or ID prefix>] [--recur=<Rule>] [--description="<Description>"]
+	Update Task: -c update --index=<Task Index or ID prefix> --name="<New Task Name>" --status=<New Task Status>
+		[--due="<Due Date>"] [--priority=<Priority>] [--tags=<Tag>,-<Removed Tag>] [--project=<Project or none>]
+		[--description="<Description>"]
+		Task Statuses: %s
+		Tasks: --index accepts indexes and ranges (--index=3-7,10), --filter or arguments after flags select tasks by a filter
+		if more than %d tasks are changed (confirm_threshold in the config), a confirmation is asked,
+		--yes confirms it without a question
+	Delete Task: -c delete --index=<Task Index or ID prefix> [--subtasks=<cascade|promote>]
+		moves the task to the trash, see -c trash and -c restore
+		cascade - delete the task with all its subtasks
+		promote - delete only the task and move its subtasks to its parent
+	Update Task Status: -c updateStatus --index=<Task Index or ID prefix> --status=<New Task Status>
+		Task Statuses: %s
+	Mark Tasks Done: -c done --index=<Task Index or ID prefix> (or --filter="<Filter>", e.g. -c done project:infra +sprint12)
+	Set Priority: -c priority --index=<Task Index or ID prefix> --priority=<Priority>
+		Priorities: none, low (L), medium (M), high (H), critical (C)
+	Set Due Date: -c due --index=<Task Index or ID prefix> --due="<Due Date>"
+		Due Dates: 2025-12-31, 2025-12-31 18:00, today, tomorrow, eod, eow, eom, fri, next fri,
+			next week, next month, in 3 days, in 2 weeks, none (removes the due date)
+	Show Task Details: -c show --index=<Task Index or ID prefix>
+	Add Note: -c note --index=<Task Index or ID prefix> --note="<Note text>"
+	Edit Task In $EDITOR: -c edit --index=<Task Index or ID prefix>
+	Set Recurrence: -c recur --index=<Task Index or ID prefix> --recur=<Rule>
+		Rules: daily, weekly, weekly:mon,fri, monthly, monthly:15, after:3d (3 days after completion), none
+		when a recurring task is done, its next occurrence is added with the next due date
+	Show Task History: -c history --index=<Task Index or ID prefix>
+		shows who changed the task, when and what was changed, also for deleted tasks
+	Show Changes Log: -c log [--since="<Date>"]
+		shows the changes of all tasks, Dates: yesterday, today, 2025-12-31, 2025-12-31 18:00
+	Archive Tasks: -c archive --index=<Task Index or ID prefix> (or --filter="<Filter>")
+		moves the tasks with their subtasks to the archive, e.g. --filter='status:closed completed<"30 days ago"'
+		archived tasks are hidden from lists, --all shows them
+	Restore Task: -c restore --index=<Task Index or ID prefix>
+		returns the task with its subtasks from the archive or the trash
+	Show Trash: -c trash (recently deleted first)
+	Empty Trash: -c empty-trash (deletes the tasks in the trash permanently)
+	Undo Changes: -c undo [<Number of Operations>]
+		reverts the last changes of tasks, the changes are kept in a journal next to the tasks file
+	Redo Changes: -c redo [<Number of Operations>]
+		applies the undone changes again, a new change clears the changes to redo
+	Link Tasks: -c link --index=<Task Index or ID prefix> --depends=<Blocking Task Index or ID prefix>
+		the task cannot be started until the blocking task is done
+	Unlink Tasks: -c unlink --index=<Task Index or ID prefix> --depends=<Blocking Task Index or ID prefix>
+	Show Tasks: -c list [--sort=<Sort Key>] [<Filter>] (or --filter="<Filter>")
+		Filter: status:open priority>=high +urgent due.before:fri name~"deploy" or project:infra
+		Fields: status (a status, new, open, closed, started), priority, project, tag, name, description, id, index,
+			urgency, due, created, updated, started, completed, archived, deleted
+			(dates: today, fri, 2025-12-31, "30 days ago", none, any)
+		Operators: : = != < <= > >= ~ (regular expression), and, or, not, ( )
+			conditions separated by spaces are joined with and, a bare word searches in task names
+			flags must be passed before the filter
+	Show Report: -c report --name=<Report Name>
+		Built-in Reports: all, next, done, notdone, inprogress, weekly (-c allTasks, -c doneTasks, -c notDoneTasks
+			and -c inProgressTasks run the all, done, notdone and inprogress reports)
+		reports are saved in the config file, see Report add in the interactive mode
+	Show Reports: -c reports
+	Show Overdue Tasks: -c overdueTasks
+	Show Tasks Due Today: -c dueTodayTasks
+	Show Tasks Due This Week: -c dueThisWeekTasks
+	Show Ready Tasks (not started and not blocked): -c readyTasks
+		Filter Any List: --filter="<Filter>" --status=<Status>,<Status> --tags=<Tag>,<Tag> --project=<Project> (includes subprojects)
+			--all also shows archived tasks
+		Sort Any List: --sort=<Sort Key>[,<Sort Key>] (urgency by default)
+			Sort Keys: urgency, priority, due, created, updated, started, completed, archived, deleted, name, status, index
+			a direction is set by :asc or :desc (--sort=due,priority:asc)
+		Group Any List: --group-by=<status|project|tag|due> (due groups tasks by week)
+		Select Columns: --columns=<Column>,<Column> (all by default)
+			Columns: index, id, name, status, priority, urgency, created, updated, completed, started, blocked,
+			subtasks, project, tags, recur, due, archived, deleted
+		Output Format: --format=<text|json|jsonl|csv|tsv|yaml|markdown> (text by default)
+			the pager and colors are used only when the output is a terminal
+	Search Tasks: -c search [--format=<Format>] <Terms>
+		searches names, tags, descriptions and notes, ignores case and tolerates typos
+	Show Tags: -c tags
+	Show Projects: -c projects
+	Show Tasks File: -c where
+	Use Another Tasks File: --file=<Path> (or TASKTRACKER_FILE environment variable)
+	Use Another Config File: --config=<Path> (or TASKTRACKER_CONFIG environment variable)
+`, statuses, filemanager.ConfirmThreshold, statuses)
 }
 
 // Handle вызывает соответствующую функцию в зависимости от переданных
 // пользователем аргументов и выводит результат в терминал.
 func (s *storage) Handle() error {
-	if s.helpFlag {
+	if s.opts.Help {
 		printHelp()
 		return nil
 	}
 
-	switch strings.ToLower(s.command) {
+	switch strings.ToLower(s.opts.Command) {
 	case "add":
-		if s.taskName == "" {
+		if s.opts.TaskName == "" {
 			return filemanager.ErrNameNotExists
 		}
-		result, err := filemanager.Add(&s.tasks, []string{s.taskName})
+		s.mu.Lock()
+		result, err := filemanager.Add(s.store, &s.tasks, []string{s.opts.TaskName, s.opts.Due, s.opts.Priority, s.opts.Tags, s.opts.Project, s.opts.Parent, s.opts.Recur, s.opts.Description})
+		s.mu.Unlock()
 		if err != nil {
 			return fmt.Errorf("filemanager.Add: %w", err)
 		}
 		fmt.Println(result)
 
 	case "update":
-		if s.taskIndex == -1 {
+		target := s.target()
+		if target == "" {
 			return filemanager.ErrIndexNotExists
 		}
-		if s.taskName == "" {
+		if s.opts.TaskName == "" {
 			return filemanager.ErrNameNotExists
 		}
-		if s.taskStatus == "" {
+		if s.opts.TaskStatus == "" {
 			return filemanager.ErrStatusNotExists
 		}
-		result, err := filemanager.Update(&s.tasks, []string{strconv.Itoa(s.taskIndex), s.taskName, s.taskStatus})
+		target, targets, err := s.confirmTargets(target, "Update")
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		result, err := filemanager.Update(s.store, &s.tasks, []string{target, s.opts.TaskName, s.opts.TaskStatus, s.opts.Due, s.opts.Priority, s.opts.Tags, s.opts.Project, s.opts.Description})
+		s.mu.Unlock()
 		if err != nil {
 			return fmt.Errorf("filemanager.Update: %w", err)
 		}
 		fmt.Println(result)
+		s.completeTargets(targets)
 
 	case "delete":
-		if s.taskIndex == -1 {
+		target := s.target()
+		if target == "" {
 			return filemanager.ErrIndexNotExists
 		}
-		result, err := filemanager.Delete(&s.tasks, []string{strconv.Itoa(s.taskIndex)})
+		target, _, err := s.confirmTargets(target, "Delete")
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		result, err := filemanager.Delete(s.store, &s.tasks, []string{target, s.opts.Subtasks})
+		s.mu.Unlock()
 		if err != nil {
 			return fmt.Errorf("filemanager.Delete: %w", err)
 		}
 		fmt.Println(result)
 
-	case "updatestatus":
-		if s.taskIndex == -1 {
+	case "updatestatus", "done":
+		target := s.target()
+		if target == "" {
 			return filemanager.ErrIndexNotExists
 		}
-		if s.taskStatus == "" {
+		status := s.opts.TaskStatus
+		if strings.ToLower(s.opts.Command) == "done" {
+			status = filemanager.DoneStatuses()[0]
+		}
+		if status == "" {
 			return filemanager.ErrStatusNotExists
 		}
-		result, err := filemanager.UpdateStatus(&s.tasks, []string{strconv.Itoa(s.taskIndex), s.taskStatus})
+		target, targets, err := s.confirmTargets(target, "Update")
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		result, err := filemanager.UpdateStatus(s.store, &s.tasks, []string{target, status})
+		s.mu.Unlock()
 		if err != nil {
 			return fmt.Errorf("filemanager.UpdateStatus: %w", err)
 		}
 		fmt.Println(result)
+		s.completeTargets(targets)
 
-	case "alltasks":
-		err := filemanager.AllTasks(&s.tasks)
-		return err
+	case "list":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.ListTasks(tasks, s.listOptions())
+
+	case "alltasks", "donetasks", "notdonetasks", "inprogresstasks":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.RunReport(tasks, reportAliases[strings.ToLower(s.opts.Command)], s.listOptions())
+
+	case "report":
+		if s.opts.TaskName == "" {
+			return filemanager.ErrReportNameNotExists
+		}
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.RunReport(tasks, s.opts.TaskName, s.listOptions())
+
+	case "reports":
+		fmt.Println(filemanager.ReportsList())
+
+	case "due":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Due == "" {
+			return filemanager.ErrDueNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.SetDue(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Due})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.SetDue: %w", err)
+		}
+		fmt.Println(result)
 
-	case "donetasks":
-		err := filemanager.DoneTasks(&s.tasks)
+	case "priority":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Priority == "" {
+			return filemanager.ErrPriorityNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.SetPriority(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Priority})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.SetPriority: %w", err)
+		}
+		fmt.Println(result)
+
+	case "show":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		result, err := filemanager.Show(s.snapshot(), s.opts.TaskIndex)
+		if err != nil {
+			return fmt.Errorf("filemanager.Show: %w", err)
+		}
+		fmt.Println(result)
+
+	case "note":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Note == "" {
+			return filemanager.ErrNoteNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.AddNote(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Note})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.AddNote: %w", err)
+		}
+		fmt.Println(result)
+
+	case "edit":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.Edit(s.store, &s.tasks, []string{s.opts.TaskIndex})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Edit: %w", err)
+		}
+		fmt.Println(result)
+		s.completeParents(s.opts.TaskIndex)
+
+	case "recur":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Recur == "" {
+			return filemanager.ErrRecurrenceNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.SetRecurrence(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Recur})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.SetRecurrence: %w", err)
+		}
+		fmt.Println(result)
+
+	case "overduetasks":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.OverdueTasks(tasks, s.listOptions())
+
+	case "duetodaytasks":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.DueTodayTasks(tasks, s.listOptions())
+
+	case "duethisweektasks":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.DueThisWeekTasks(tasks, s.listOptions())
+
+	case "link":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Depends == "" {
+			return filemanager.ErrDependencyNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.Link(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Depends})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Link: %w", err)
+		}
+		fmt.Println(result)
+
+	case "unlink":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		if s.opts.Depends == "" {
+			return filemanager.ErrDependencyNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.Unlink(s.store, &s.tasks, []string{s.opts.TaskIndex, s.opts.Depends})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Unlink: %w", err)
+		}
+		fmt.Println(result)
+
+	case "archive":
+		if s.target() == "" {
+			return filemanager.ErrArchiveTargetNotExists
+		}
+		target, _, err := s.confirmTargets(s.target(), "Archive")
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		result, err := filemanager.Archive(s.store, &s.tasks, []string{target, ""})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Archive: %w", err)
+		}
+		fmt.Println(result)
+
+	case "restore":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		s.mu.Lock()
+		result, err := filemanager.Restore(s.store, &s.tasks, []string{s.opts.TaskIndex})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Restore: %w", err)
+		}
+		fmt.Println(result)
+
+	case "trash":
+		err := filemanager.TrashTasks(s.store, s.listOptions())
 		return err
 
-	case "notdonetasks":
-		err := filemanager.NotDoneTasks(&s.tasks)
+	case "empty-trash", "emptytrash":
+		s.mu.Lock()
+		result, err := filemanager.EmptyTrash(s.store, &s.tasks, nil)
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.EmptyTrash: %w", err)
+		}
+		fmt.Println(result)
+
+	case "history":
+		if s.opts.TaskIndex == "" {
+			return filemanager.ErrIndexNotExists
+		}
+		result, err := filemanager.History(s.store, s.snapshot(), s.opts.TaskIndex)
+		if err != nil {
+			return fmt.Errorf("filemanager.History: %w", err)
+		}
+		fmt.Println(result)
+
+	case "log":
+		err := filemanager.Log(s.store, s.opts.Since)
 		return err
 
-	case "inprogresstasks":
-		err := filemanager.InProgressTasks(&s.tasks)
+	case "undo":
+		s.mu.Lock()
+		result, err := filemanager.Undo(s.store, &s.tasks, []string{s.opts.Args})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Undo: %w", err)
+		}
+		fmt.Println(result)
+
+	case "redo":
+		s.mu.Lock()
+		result, err := filemanager.Redo(s.store, &s.tasks, []string{s.opts.Args})
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("filemanager.Redo: %w", err)
+		}
+		fmt.Println(result)
+
+	case "readytasks", "ready":
+		tasks, err := s.listed()
+		if err != nil {
+			return err
+		}
+		return filemanager.ReadyTasks(tasks, s.listOptions())
+
+	case "search":
+		err := filemanager.SearchTasks(s.snapshot(), s.opts.Args, s.opts.Format)
 		return err
 
+	case "tags":
+		filemanager.Tags(s.snapshot())
+
+	case "projects":
+		filemanager.Projects(s.snapshot())
+
+	case "where":
+		fmt.Println(s.store.Where())
+
 	case "help":
 	default:
 		return filemanager.ErrInvalidCommand
@@ -2,18 +2,54 @@ package models
 
 import "time"
 
-type TaskStatus int
+// TaskStatus — название статуса задачи. Набор статусов задается в настройках приложения.
+type TaskStatus string
+
+// Priority описывает приоритет задачи. Чем больше значение, тем выше приоритет.
+type Priority int
 
 // Task структура описывает сущность Task.
+// Index — номер задачи для пользователя, ID — неизменяемый уникальный идентификатор задачи.
+// Временные метки заполняются автоматически при создании задачи и смене ее статуса, Due — срок выполнения.
+// Project может быть иерархическим: уровни разделяются точкой (backend.auth).
+// ParentID содержит ID родительской задачи, если задача является подзадачей.
+// DependsOn содержит ID задач, которые должны быть выполнены до начала работы над задачей.
+// Recur — правило повторения задачи, SeriesID — ID первой задачи серии повторений.
+// Description — многострочное описание задачи, Notes — заметки с временем добавления.
+// ArchivedAt и DeletedAt заполнены у задач, перенесенных в архив или в корзину.
 type Task struct {
-	Index  int        `json:"index"`
-	Name   string     `json:"name"`
-	Status TaskStatus `json:"status"`
+	ID          string     `json:"id"`
+	Index       int        `json:"index"`
+	Name        string     `json:"name"`
+	Status      TaskStatus `json:"status"`
+	CreatedAt   time.Time  `json:"created_at,omitzero"`
+	UpdatedAt   time.Time  `json:"updated_at,omitzero"`
+	StartedAt   time.Time  `json:"started_at,omitzero"`
+	CompletedAt time.Time  `json:"completed_at,omitzero"`
+	Due         time.Time  `json:"due,omitzero"`
+	Priority    Priority   `json:"priority,omitzero"`
+	Tags        []string   `json:"tags,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	Recur       string     `json:"recur,omitempty"`
+	SeriesID    string     `json:"series_id,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Notes       []Note     `json:"notes,omitempty"`
+	ArchivedAt  time.Time  `json:"archived_at,omitzero"`
+	DeletedAt   time.Time  `json:"deleted_at,omitzero"`
+}
+
+// Note — заметка к задаче. Заметки только добавляются и не изменяются после создания.
+type Note struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
 }
 
 const (
-	StatusNotDone TaskStatus = iota
-	StatusInProgress
-	StatusDone
-	TimeOut = time.Second * 5
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityCritical
 )
@@ -0,0 +1,289 @@
+package query
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/dates"
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// fieldNames перечисляет поля, доступные в фильтре, для сообщений об ошибках.
+const fieldNames = "status, priority, project, tag, name, description, id, index, urgency, due, created, updated, started, completed, archived, deleted"
+
+// dateFields сопоставляет названиям полей-дат временные метки задачи.
+var dateFields = map[string]func(t models.Task) time.Time{
+	"due":       func(t models.Task) time.Time { return t.Due },
+	"created":   func(t models.Task) time.Time { return t.CreatedAt },
+	"updated":   func(t models.Task) time.Time { return t.UpdatedAt },
+	"started":   func(t models.Task) time.Time { return t.StartedAt },
+	"completed": func(t models.Task) time.Time { return t.CompletedAt },
+	"archived":  func(t models.Task) time.Time { return t.ArchivedAt },
+	"deleted":   func(t models.Task) time.Time { return t.DeletedAt },
+}
+
+// textFields сопоставляет названиям текстовых полей значения задачи.
+var textFields = map[string]func(t models.Task) string{
+	"name":        func(t models.Task) string { return t.Name },
+	"description": func(t models.Task) string { return t.Description },
+}
+
+// condition строит условие "поле оператор значение". Поле может содержать модификатор: due.before:fri.
+func (p *parser) condition(field, op, value token) (node, error) {
+	name, modifier, _ := strings.Cut(strings.ToLower(field.text), ".")
+	if modifier != "" {
+		if _, ok := dateFields[name]; !ok {
+			return nil, newError(field.pos, "modifier %q is only supported for dates", modifier)
+		}
+		if op.text != ":" && op.text != "=" {
+			return nil, newError(op.pos, "operator %q cannot be used with modifier %q", op.text, modifier)
+		}
+		switch modifier {
+		case "before":
+			op.text = "<"
+		case "after":
+			op.text = ">"
+		default:
+			return nil, newError(field.pos+len([]rune(name))+1, "unknown modifier %q (available: before, after)", modifier)
+		}
+	}
+
+	if get, ok := dateFields[name]; ok {
+		return p.dateCondition(get, op, value)
+	}
+	if get, ok := textFields[name]; ok {
+		if !slices.Contains([]string{":", "=", "!=", "~"}, op.text) {
+			return nil, unsupportedOperator(op, name)
+		}
+		if op.text == "~" {
+			return regexpCondition(get, value)
+		}
+		return textCondition(get, op.text, value.text), nil
+	}
+
+	switch name {
+	case "status":
+		return p.statusCondition(op, value)
+	case "priority":
+		return p.priorityCondition(op, value)
+	case "project":
+		return projectCondition(op, value)
+	case "tag", "tags":
+		switch op.text {
+		case ":", "=":
+			return tagCondition(value.text, true), nil
+		case "!=":
+			return tagCondition(value.text, false), nil
+		}
+		return nil, unsupportedOperator(op, name)
+	case "id":
+		if op.text != ":" && op.text != "=" {
+			return nil, unsupportedOperator(op, name)
+		}
+		prefix := strings.ToLower(value.text)
+		return predicate(func(t models.Task) bool { return strings.HasPrefix(strings.ToLower(t.ID), prefix) }), nil
+	case "index":
+		number, err := strconv.Atoi(value.text)
+		if err != nil {
+			return nil, newError(value.pos, "expected a task number, got %q", value.text)
+		}
+		return compareCondition(op, name, func(t models.Task) int { return cmp.Compare(t.Index, number) })
+	case "urgency":
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, newError(value.pos, "expected a number, got %q", value.text)
+		}
+		if p.env.Urgency == nil {
+			return nil, newError(field.pos, "urgency is not available")
+		}
+		return compareCondition(op, name, func(t models.Task) int { return cmp.Compare(p.env.Urgency(t), number) })
+	}
+
+	return nil, newError(field.pos, "unknown field %q (available: %s)", field.text, fieldNames)
+}
+
+// unsupportedOperator возвращает ошибку для оператора, который не применим к полю.
+func unsupportedOperator(op token, field string) *Error {
+	return newError(op.pos, "operator %q is not supported for %s", op.text, field)
+}
+
+// compareCondition строит условие сравнения по результату compare (отрицательный, ноль или положительный).
+func compareCondition(op token, field string, compare func(t models.Task) int) (node, error) {
+	var match func(c int) bool
+	switch op.text {
+	case ":", "=":
+		match = func(c int) bool { return c == 0 }
+	case "!=":
+		match = func(c int) bool { return c != 0 }
+	case "<":
+		match = func(c int) bool { return c < 0 }
+	case "<=":
+		match = func(c int) bool { return c <= 0 }
+	case ">":
+		match = func(c int) bool { return c > 0 }
+	case ">=":
+		match = func(c int) bool { return c >= 0 }
+	default:
+		return nil, unsupportedOperator(op, field)
+	}
+
+	return predicate(func(t models.Task) bool { return match(compare(t)) }), nil
+}
+
+// tagCondition проверяет наличие (present) или отсутствие тега у задачи.
+func tagCondition(text string, present bool) node {
+	return predicate(func(t models.Task) bool {
+		has := slices.ContainsFunc(t.Tags, func(tag string) bool { return strings.EqualFold(tag, text) })
+		return has == present
+	})
+}
+
+// textCondition сравнивает текстовое поле без учета регистра: ":" — содержит, "=" — совпадает, "!=" — не совпадает.
+func textCondition(get func(t models.Task) string, op string, value string) node {
+	value = strings.ToLower(value)
+	return predicate(func(t models.Task) bool {
+		text := strings.ToLower(get(t))
+		switch op {
+		case "=":
+			return text == value
+		case "!=":
+			return text != value
+		}
+		return strings.Contains(text, value)
+	})
+}
+
+// regexpCondition проверяет текстовое поле регулярным выражением без учета регистра.
+func regexpCondition(get func(t models.Task) string, value token) (node, error) {
+	re, err := regexp.Compile("(?i)" + value.text)
+	if err != nil {
+		return nil, newError(value.pos, "invalid regular expression: %v", err)
+	}
+
+	return predicate(func(t models.Task) bool { return re.MatchString(get(t)) }), nil
+}
+
+// statusCondition проверяет статус задачи. Значение — названия статусов или групп статусов из Env.
+func (p *parser) statusCondition(op token, value token) (node, error) {
+	if op.text != ":" && op.text != "=" && op.text != "!=" {
+		return nil, unsupportedOperator(op, "status")
+	}
+	if p.env.Statuses == nil {
+		return nil, newError(value.pos, "statuses are not available")
+	}
+
+	// Несколько статусов перечисляются через запятую: status:todo,in-progress.
+	var statuses []models.TaskStatus
+	pos := value.pos
+	for _, name := range strings.Split(value.text, ",") {
+		found, err := p.env.Statuses(name)
+		if err != nil {
+			return nil, newError(pos, "%v", err)
+		}
+		statuses = append(statuses, found...)
+		pos += utf8.RuneCountInString(name) + 1
+	}
+
+	want := op.text != "!="
+	return predicate(func(t models.Task) bool { return slices.Contains(statuses, t.Status) == want }), nil
+}
+
+// priorityCondition сравнивает приоритет задачи: priority>=high.
+func (p *parser) priorityCondition(op token, value token) (node, error) {
+	if p.env.Priority == nil {
+		return nil, newError(value.pos, "priorities are not available")
+	}
+
+	priority, err := p.env.Priority(value.text)
+	if err != nil {
+		return nil, newError(value.pos, "%v", err)
+	}
+
+	return compareCondition(op, "priority", func(t models.Task) int { return cmp.Compare(t.Priority, priority) })
+}
+
+// projectCondition проверяет проект задачи: ":" включает подпроекты (project:work подходит для work.backend),
+// "=" требует точного совпадения, "!=" исключает проект вместе с подпроектами. Значение none означает задачи
+// без проекта.
+func projectCondition(op token, value token) (node, error) {
+	project := strings.ToLower(value.text)
+	if project == "none" {
+		project = ""
+	}
+
+	var match func(t string) bool
+	switch {
+	case project == "":
+		match = func(t string) bool { return t == "" }
+	case op.text == "=":
+		match = func(t string) bool { return t == project }
+	default:
+		match = func(t string) bool { return t == project || strings.HasPrefix(t, project+".") }
+	}
+
+	switch op.text {
+	case ":", "=":
+		return predicate(func(t models.Task) bool { return match(strings.ToLower(t.Project)) }), nil
+	case "!=":
+		return predicate(func(t models.Task) bool { return !match(strings.ToLower(t.Project)) }), nil
+	}
+
+	return nil, unsupportedOperator(op, "project")
+}
+
+// dateCondition сравнивает временную метку задачи с датой. Значение без времени (fri, 2024-05-01) означает
+// весь день: due<fri — раньше пятницы, due<=fri — не позже конца пятницы, due:fri — в пятницу.
+// Значения none и any проверяют, что метка не заполнена или заполнена.
+func (p *parser) dateCondition(get func(t models.Task) time.Time, op token, value token) (node, error) {
+	switch strings.ToLower(value.text) {
+	case "none", "any":
+		if op.text != ":" && op.text != "=" && op.text != "!=" {
+			return nil, newError(op.pos, "operator %q cannot be used with %q", op.text, value.text)
+		}
+		want := (strings.ToLower(value.text) == "none") == (op.text != "!=")
+		return predicate(func(t models.Task) bool { return get(t).IsZero() == want }), nil
+	}
+
+	now := p.env.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	end, err := dates.Parse(value.text, now)
+	if err != nil {
+		return nil, newError(value.pos, "invalid date %q", value.text)
+	}
+	start := end
+	if end.Equal(dates.EndOfDay(end)) {
+		start = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+	}
+
+	var match func(t time.Time) bool
+	switch op.text {
+	case ":", "=":
+		match = func(t time.Time) bool { return !t.Before(start) && !t.After(end) }
+	case "!=":
+		match = func(t time.Time) bool { return t.Before(start) || t.After(end) }
+	case "<":
+		match = func(t time.Time) bool { return t.Before(start) }
+	case "<=":
+		match = func(t time.Time) bool { return !t.After(end) }
+	case ">":
+		match = func(t time.Time) bool { return t.After(end) }
+	case ">=":
+		match = func(t time.Time) bool { return !t.Before(start) }
+	default:
+		return nil, newError(op.pos, "operator %q is not supported for dates", op.text)
+	}
+
+	// Незаполненная метка не подходит ни под одно сравнение с датой.
+	return predicate(func(t models.Task) bool {
+		at := get(t)
+		return !at.IsZero() && match(at.In(now.Location()))
+	}), nil
+}
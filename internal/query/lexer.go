@@ -0,0 +1,169 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind — вид лексемы выражения фильтра.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// tokenWord — слово без кавычек: название поля, значение или ключевое слово and/or/not.
+	tokenWord
+	// tokenString — строка в кавычках.
+	tokenString
+	// tokenOp — оператор сравнения: ":", "=", "!=", "<", "<=", ">", ">=", "~".
+	tokenOp
+	// tokenTag — тег с признаком наличия (+tag) или отсутствия (-tag).
+	tokenTag
+	tokenLParen
+	tokenRParen
+)
+
+// token — лексема выражения с позицией ее начала (номер символа, начиная с 1).
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value bool
+}
+
+// operators перечислены так, чтобы двухсимвольные операторы проверялись раньше односимвольных.
+var operators = []string{"!=", "<=", ">=", ":", "=", "<", ">", "~"}
+
+// lexer разбивает выражение фильтра на лексемы. После оператора сравнения значение считывается
+// до пробела или закрывающей скобки, поэтому в значениях допускаются двоеточия (weekly:mon, 18:00).
+type lexer struct {
+	input string
+	// offset — смещение в байтах, pos — номер текущего символа.
+	offset  int
+	pos     int
+	afterOp bool
+}
+
+// peekRune возвращает текущий символ и его размер в байтах без продвижения. Некорректный байт UTF-8
+// возвращается как utf8.RuneError размером 1, поэтому продвижение на size не выходит за конец ввода.
+func (l *lexer) peekRune() (rune, int) {
+	if l.offset >= len(l.input) {
+		return utf8.RuneError, 0
+	}
+
+	return utf8.DecodeRuneInString(l.input[l.offset:])
+}
+
+// advance продвигает лексер на n байт, пересчитывая номер символа.
+func (l *lexer) advance(n int) {
+	l.pos += utf8.RuneCountInString(l.input[l.offset : l.offset+n])
+	l.offset += n
+}
+
+// isWordRune сообщает, может ли символ входить в слово без кавычек.
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()"':=!<>~`, r)
+}
+
+// next возвращает следующую лексему.
+func (l *lexer) next() (token, error) {
+	if l.afterOp {
+		// Значение должно следовать сразу за оператором. Пустое слово означает, что значение пропущено.
+		l.afterOp = false
+		start, rest := l.pos, l.input[l.offset:]
+		if r, _ := l.peekRune(); l.offset < len(l.input) && (r == '"' || r == '\'') {
+			return l.readString(r)
+		}
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == ')' })
+		if end == -1 {
+			end = len(rest)
+		}
+		l.advance(end)
+		return token{kind: tokenWord, text: rest[:end], pos: start}, nil
+	}
+
+	for l.offset < len(l.input) {
+		r, size := l.peekRune()
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.advance(size)
+	}
+
+	start := l.pos
+	if l.offset >= len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	rest := l.input[l.offset:]
+	r, _ := l.peekRune()
+
+	if r == '"' || r == '\'' {
+		return l.readString(r)
+	}
+
+	switch r {
+	case '(':
+		l.advance(1)
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case ')':
+		l.advance(1)
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.advance(len(op))
+			l.afterOp = true
+			return token{kind: tokenOp, text: op, pos: start}, nil
+		}
+	}
+
+	if r == '+' || r == '-' {
+		end := strings.IndexFunc(rest[1:], func(r rune) bool { return !isWordRune(r) })
+		if end == -1 {
+			end = len(rest) - 1
+		}
+		if end > 0 {
+			l.advance(end + 1)
+			return token{kind: tokenTag, text: rest[1 : end+1], pos: start, value: r == '+'}, nil
+		}
+	}
+
+	end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+	if end == -1 {
+		end = len(rest)
+	}
+	if end == 0 {
+		return token{}, newError(start, "unexpected character %q", r)
+	}
+
+	l.advance(end)
+	return token{kind: tokenWord, text: rest[:end], pos: start}, nil
+}
+
+// readString считывает строку в кавычках quote. Внутри строки кавычку можно экранировать обратной косой чертой.
+func (l *lexer) readString(quote rune) (token, error) {
+	start := l.pos
+	l.advance(1)
+
+	var text strings.Builder
+	for l.offset < len(l.input) {
+		r, size := l.peekRune()
+
+		switch {
+		case r == quote:
+			l.advance(size)
+			return token{kind: tokenString, text: text.String(), pos: start}, nil
+		case r == '\\' && l.offset+1 < len(l.input):
+			l.advance(1)
+			r, size = l.peekRune()
+		}
+
+		text.WriteRune(r)
+		l.advance(size)
+	}
+
+	return token{}, newError(start, "unterminated string")
+}
@@ -0,0 +1,306 @@
+// Package query реализует язык фильтров для списков задач, например:
+//
+//	status:open priority>=high +urgent due.before:fri name~"deploy" or project:infra
+//
+// Условия, записанные подряд, объединяются через and. Поддерживаются операторы and, or, not и скобки;
+// and связывает сильнее, чем or.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+var ErrSyntax error = errors.New("invalid filter")
+
+// Error — ошибка в выражении фильтра с номером символа, на котором она обнаружена.
+type Error struct {
+	Pos int
+	Msg string
+	// Input — выражение фильтра, в котором найдена ошибка. Заполняется функцией Parse.
+	Input string
+}
+
+func newError(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Error выводит сообщение об ошибке, выражение и указатель на место ошибки.
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%v at position %d: %s", ErrSyntax, e.Pos, e.Msg)
+	if e.Input == "" {
+		return message
+	}
+
+	return fmt.Sprintf("%s\n  %s\n  %s^", message, e.Input, strings.Repeat(" ", max(e.Pos-1, 0)))
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrSyntax).
+func (e *Error) Unwrap() error {
+	return ErrSyntax
+}
+
+// Env — сведения, которые фильтру нужны от приложения: текущее время и разбор значений,
+// набор которых задается настройками.
+type Env struct {
+	Now time.Time
+	// Statuses возвращает статусы, на которые указывает значение фильтра: название статуса или группу статусов.
+	Statuses func(value string) ([]models.TaskStatus, error)
+	// Priority разбирает приоритет.
+	Priority func(value string) (models.Priority, error)
+	// Urgency вычисляет срочность задачи.
+	Urgency func(task models.Task) float64
+}
+
+// Query — разобранное выражение фильтра.
+type Query struct {
+	root node
+}
+
+// Match сообщает, подходит ли задача под фильтр. Пустой фильтр подходит для любой задачи.
+func (q *Query) Match(task models.Task) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+
+	return q.root.match(task)
+}
+
+// Parse разбирает выражение фильтра. Значения полей проверяются сразу, поэтому ошибки в названиях статусов,
+// приоритетах и датах также возвращаются с позицией.
+func Parse(input string, env Env) (*Query, error) {
+	if !utf8.ValidString(input) {
+		pos := 1
+		for offset := 0; ; pos++ {
+			r, size := utf8.DecodeRuneInString(input[offset:])
+			if r == utf8.RuneError && size == 1 {
+				break
+			}
+			offset += size
+		}
+		return nil, &Error{Pos: pos, Msg: "invalid UTF-8 character", Input: strings.ToValidUTF8(input, "?")}
+	}
+
+	p := &parser{
+		lexer: &lexer{input: input, pos: 1},
+		env:   env,
+	}
+
+	root, err := p.parse()
+	if err != nil {
+		var queryErr *Error
+		if errors.As(err, &queryErr) {
+			queryErr.Input = input
+		}
+		return nil, err
+	}
+
+	return &Query{root: root}, nil
+}
+
+// node — узел дерева выражения.
+type node interface {
+	match(task models.Task) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(task models.Task) bool { return n.left.match(task) && n.right.match(task) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(task models.Task) bool { return n.left.match(task) || n.right.match(task) }
+
+type notNode struct{ operand node }
+
+func (n notNode) match(task models.Task) bool { return !n.operand.match(task) }
+
+// predicate — условие на одно поле задачи.
+type predicate func(task models.Task) bool
+
+func (p predicate) match(task models.Task) bool { return p(task) }
+
+// parser разбирает выражение методом рекурсивного спуска:
+//
+//	expr  = and { "or" and }
+//	and   = unary { ["and"] unary }
+//	unary = "not" unary | "(" expr ")" | term
+//	term  = +tag | -tag | field op value | word | "string"
+type parser struct {
+	lexer *lexer
+	env   Env
+	// current — текущая лексема, peeked — признак того, что она уже считана.
+	current token
+	peeked  bool
+}
+
+// peek возвращает текущую лексему, не продвигая разбор.
+func (p *parser) peek() (token, error) {
+	if !p.peeked {
+		tok, err := p.lexer.next()
+		if err != nil {
+			return token{}, err
+		}
+		p.current = tok
+		p.peeked = true
+	}
+
+	return p.current, nil
+}
+
+// take возвращает текущую лексему и переходит к следующей.
+func (p *parser) take() (token, error) {
+	tok, err := p.peek()
+	p.peeked = false
+	return tok, err
+}
+
+// isKeyword сообщает, что лексема — ключевое слово word.
+func isKeyword(tok token, word string) bool {
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) parse() (node, error) {
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == tokenEOF {
+		return nil, nil
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err = p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind != tokenEOF {
+		return nil, newError(tok.pos, "unexpected %q", tok.text)
+	}
+
+	return root, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !isKeyword(tok, "or") {
+			return left, nil
+		}
+		p.take()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if tok.kind == tokenEOF || tok.kind == tokenRParen || isKeyword(tok, "or") {
+			return left, nil
+		}
+		if isKeyword(tok, "and") {
+			p.take()
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok, err := p.take()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case tok.kind == tokenEOF:
+		return nil, newError(tok.pos, "unexpected end of filter, expected a condition")
+
+	case isKeyword(tok, "not"):
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+
+	case tok.kind == tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, err := p.take()
+		if err != nil {
+			return nil, err
+		}
+		if closing.kind != tokenRParen {
+			return nil, newError(closing.pos, "expected ')' to close '(' at position %d", tok.pos)
+		}
+		return inner, nil
+
+	case tok.kind == tokenTag:
+		return tagCondition(tok.text, tok.value), nil
+
+	case tok.kind == tokenString:
+		return textCondition(func(t models.Task) string { return t.Name }, ":", tok.text), nil
+
+	case tok.kind == tokenWord:
+		if isKeyword(tok, "and") || isKeyword(tok, "or") {
+			return nil, newError(tok.pos, "expected a condition before %q", tok.text)
+		}
+
+		op, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if op.kind != tokenOp {
+			return textCondition(func(t models.Task) string { return t.Name }, ":", tok.text), nil
+		}
+		p.take()
+
+		value, err := p.take()
+		if err != nil {
+			return nil, err
+		}
+		if value.kind == tokenWord && value.text == "" {
+			return nil, newError(value.pos, "expected a value after %q", tok.text+op.text)
+		}
+
+		return p.condition(tok, op, value)
+	}
+
+	return nil, newError(tok.pos, "unexpected %q", tok.text)
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/NikitaTumanov/terminalTaskTracker/internal/models"
+)
+
+// testEnv — окружение фильтра со статусами todo, in-progress, done и группой open.
+func testEnv() Env {
+	priorities := map[string]models.Priority{
+		"low":    models.PriorityLow,
+		"medium": models.PriorityMedium,
+		"high":   models.PriorityHigh,
+	}
+
+	return Env{
+		Now: time.Date(2026, time.January, 31, 10, 0, 0, 0, time.UTC),
+		Statuses: func(value string) ([]models.TaskStatus, error) {
+			switch value {
+			case "todo", "in-progress", "done":
+				return []models.TaskStatus{models.TaskStatus(value)}, nil
+			case "open":
+				return []models.TaskStatus{"todo", "in-progress"}, nil
+			}
+			return nil, fmt.Errorf("unknown status %q", value)
+		},
+		Priority: func(value string) (models.Priority, error) {
+			priority, ok := priorities[value]
+			if !ok {
+				return 0, fmt.Errorf("unknown priority %q", value)
+			}
+			return priority, nil
+		},
+	}
+}
+
+func testTasks() []models.Task {
+	return []models.Task{
+		{
+			Index:    1,
+			Name:     "Deploy backend",
+			Status:   "in-progress",
+			Priority: models.PriorityHigh,
+			Tags:     []string{"urgent"},
+			Project:  "work.backend",
+			Due:      time.Date(2026, time.February, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Index:    2,
+			Name:     "Write docs",
+			Status:   "done",
+			Priority: models.PriorityLow,
+			Project:  "work",
+		},
+		{
+			Index:  3,
+			Name:   "Buy milk",
+			Status: "todo",
+			Tags:   []string{"home"},
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []int
+	}{
+		{"", []int{1, 2, 3}},
+		{"status:todo", []int{3}},
+		{"status:open", []int{1, 3}},
+		{"status:todo,done", []int{2, 3}},
+		{"status!=done", []int{1, 3}},
+		{"+urgent", []int{1}},
+		{"-urgent", []int{2, 3}},
+		{"deploy", []int{1}},
+		{`"buy milk"`, []int{3}},
+		{"name~^write", []int{2}},
+		{"name=buy", nil},
+		{"priority>=medium", []int{1}},
+		{"priority<high", []int{2, 3}},
+		{"project:work", []int{1, 2}},
+		{"project=work", []int{2}},
+		{"project!=work", []int{3}},
+		{"project:none", []int{3}},
+		{"due:any", []int{1}},
+		{"due:none", []int{2, 3}},
+		{"due:2026-02-01", []int{1}},
+		{"due.before:2026-02-01", nil},
+		{"due<=2026-02-01", []int{1}},
+		{"+urgent or +home", []int{1, 3}},
+		{"project:work and not status:done", []int{1}},
+		{"(+home or +urgent) status:open", []int{1, 3}},
+		{"+home or +urgent status:done", []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			q, err := Parse(tt.filter, testEnv())
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.filter, err)
+			}
+
+			var got []int
+			for _, task := range testTasks() {
+				if q.Match(task) {
+					got = append(got, task.Index)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Parse(%q) matches tasks %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		pos    int
+	}{
+		{"status:blocked", 8},
+		{"priority>=urgent", 11},
+		{"color:red", 1},
+		{"(+home", 7},
+		{"+home or", 9},
+		{"and +home", 1},
+		{"name~(", 6},
+		{"due>someday", 5},
+		{"project>work", 8},
+		{"name:\"\xff\"", 7},
+		{"+home \xff", 7},
+		{"задача \xff", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := Parse(tt.filter, testEnv())
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.filter, err, ErrSyntax)
+			}
+
+			var queryErr *Error
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Parse(%q) error has type %T, want *Error", tt.filter, err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error position = %d, want %d", tt.filter, queryErr.Pos, tt.pos)
+			}
+		})
+	}
+}
+
+// TestLexerInvalidUTF8 проверяет, что лексер не выходит за конец ввода на некорректных байтах UTF-8,
+// даже если ввод не прошел проверку в Parse.
+func TestLexerInvalidUTF8(t *testing.T) {
+	for _, input := range []string{"\"\xff", "\"a\\\xff\"", "\xff", " \xff\xfe", "name:\xff"} {
+		l := &lexer{input: input, pos: 1}
+		for i := 0; i < len(input)+1; i++ {
+			tok, err := l.next()
+			if err != nil || tok.kind == tokenEOF {
+				break
+			}
+		}
+	}
+}
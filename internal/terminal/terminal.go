@@ -0,0 +1,15 @@
+// Package terminal определяет, подключен ли поток ввода или вывода к терминалу.
+package terminal
+
+import "os"
+
+// isCharDevice сообщает, является ли файл символьным устройством. Используется там, где нет более точной проверки:
+// символьным устройством является и терминал, и, например, /dev/null.
+func isCharDevice(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// IsTerminal сообщает, подключен ли файл к терминалу. Проверка выполняется запросом настроек терминала (TCGETS),
+// который завершается ошибкой для файлов, каналов и устройств вроде /dev/null.
+func IsTerminal(file *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+
+	return errno == 0
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package terminal
+
+import "os"
+
+// IsTerminal сообщает, подключен ли файл к терминалу.
+func IsTerminal(file *os.File) bool {
+	return isCharDevice(file)
+}
@@ -0,0 +1,12 @@
+// Package watcher реализует отслеживание изменений файла задач.
+// В Linux используется inotify, в остальных ОС — периодическая проверка времени изменения файла.
+package watcher
+
+// notify неблокирующе отправляет сигнал об изменении. Если предыдущий сигнал еще не обработан,
+// новый не добавляется: получателю достаточно знать, что файл нужно перечитать.
+func notify(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
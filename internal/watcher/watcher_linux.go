@@ -0,0 +1,83 @@
+//go:build linux
+
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE
+	// eventHeaderSize соответствует размеру struct inotify_event без поля name.
+	eventHeaderSize = syscall.SizeofInotifyEvent
+)
+
+// Watch начинает отслеживать файл path через inotify и возвращает канал, в который приходит сигнал
+// после каждого изменения файла. Наблюдение ведется за директорией, потому что файл задач
+// заменяется через rename. Канал закрывается после отмены ctx.
+func Watch(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("syscall.InotifyInit1: %w", err)
+	}
+
+	_, err = syscall.InotifyAddWatch(fd, filepath.Dir(path), watchMask)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("syscall.InotifyAddWatch: %w", err)
+	}
+
+	// Неблокирующий дескриптор регистрируется в планировщике Go, поэтому Close прерывает ожидающий Read.
+	file := os.NewFile(uintptr(fd), "inotify")
+	name := []byte(filepath.Base(path))
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	go func() {
+		defer close(changes)
+
+		buf := make([]byte, 64*(eventHeaderSize+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+
+			if containsName(buf[:n], name) {
+				notify(changes)
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// containsName разбирает прочитанные события inotify и проверяет, относится ли хотя бы одно из них к файлу name.
+func containsName(buf, name []byte) bool {
+	for offset := 0; offset+eventHeaderSize <= len(buf); {
+		nameLen := int(binary.NativeEndian.Uint32(buf[offset+12 : offset+16]))
+		start := offset + eventHeaderSize
+		end := start + nameLen
+		if end > len(buf) {
+			return false
+		}
+
+		if bytes.Equal(bytes.TrimRight(buf[start:end], "\x00"), name) {
+			return true
+		}
+
+		offset = end
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+//go:build !linux
+
+package watcher
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+const (
+	pollInterval = time.Second
+)
+
+// Watch периодически проверяет время изменения и размер файла path и возвращает канал,
+// в который приходит сигнал после каждого изменения. Канал закрывается после отмены ctx.
+func Watch(ctx context.Context, path string) (<-chan struct{}, error) {
+	changes := make(chan struct{}, 1)
+	last, _ := os.Stat(path)
+
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+
+				if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+					notify(changes)
+				}
+				last = info
+			}
+		}
+	}()
+
+	return changes, nil
+}